@@ -12,12 +12,16 @@ import (
 	//"sort"
 	"strconv"
 	"strings"
+	"sync"
 
 	// "github.com/flosch/pongo2"
 
 	"wojones.com/src/dicegame"
+	"wojones.com/src/diceodds"
 	"wojones.com/src/dicescore"
 	"wojones.com/src/diceturn"
+	"wojones.com/src/simulate"
+	"wojones.com/src/tournament"
 	//"wojones.com/src/diceturn"
 )
 
@@ -36,9 +40,29 @@ var cmdz = []cmd{
 	{"status", givestatus, "", "show current game status"},
 	{"score", showscore, "", "show the scorecard"},
 	{"history", showhist, "", "Display the game history"},
+	{"turns", showturns, "[<turn>]", "show the game's turns in turn notation, or read one (e.g. Alice: +2+2+5 22+1 = 5) under the game's rules"},
+	{"log", showlog, "", "show the log of everything done in the game"},
 	{"rollcheck", rollcheck, "<rollbits>", "check validity of a roll"},
-	{"roll", rolldice, "<d0> <d1> <d2>", "roll with given values (0 is a keep)"},
-	{"passto", passto, "<player>", "end turn and pass dice to specified player"},
+	{"roll", rolldice, "<d0> <d1> <d2> [off <offbits>]", "roll with given values (0 is a keep); offbits left the table"},
+	{"toss", tossdice, "<rollbits> [<offbits>]", "roll the given dice with the game's dice source; offbits left the table"},
+	{"passto", passto, "[<player>]", "end turn and pass dice to specified player, or list who they can go to"},
+	{"rules", showrules, "", "show the house rules for the game"},
+	{"hint", hint, "", "show the odds of each move open to the roller, best first"},
+	{"simulate", simulateturns, "<strategy>[,<strategy>...] [<turns>] [<seed>]", "play lots of turns under the game's rules and show how they come out"},
+	{"tournament", playtournament, tournamentUsage, "play lots of whole games between bot strategies, one per seat, and show who loses; rules= may be given more than once; csv=- writes CSV to the terminal"},
+	{"undo", undo, "", "take back the last roll, pass or stand-in in this round"},
+	{"redo", redo, "", "put back what was last undone"},
+	{"newround", newround, "", "start a new round once somebody has filled a chevron"},
+	{"watch", watchgame, "", "toggle printing the game's events as they happen"},
+	{"games", listgames, "", "list the games"},
+	{"newgame", newgame, "<gameid> <p1>[:<bot>] <p2>[:<bot>] [<p3>[:<bot>] ...]", "start a new game and switch to it; player:strategy has the computer play that seat"},
+	{"bot", setbot, "<player> [<strategy>]", "have the computer play a seat, or with no strategy give it back; strategies: " + strings.Join(dicegame.StrategyNames(), ", ")},
+	{"addplayer", addplayer, "<player>", "add a player to the game, between turns"},
+	{"removeplayer", removeplayer, "<player>", "take a player out of the game, between turns"},
+	{"standin", standin, "[<roller>]", "someone else rolls this turn for the player with the dice; no roller to stop"},
+	{"usegame", usegame, "<gameid>", "switch to another game"},
+	{"archive", archivegame, "<gameid>", "archive a game"},
+	{"pay", paychevron, "<player> <chevron>", "player paid up for a filled chevron (from 1)"},
 }
 
 type cmdhelp struct {
@@ -49,34 +73,115 @@ type cmdhelp struct {
 
 var cmddoc = []cmdhelp{}
 
-var tdg = dicegame.NewGame("Game001", "Freddy", "Danny", "Smeck")
+// All the games we're playing, and the one the command line is playing
+var games = dicegame.NewRegistry()
+var tdg = defaultGame()
+var tdgmu sync.Mutex
 var starttime = time.Now()
 
+// playing - the game the command line is playing
+func playing() *dicegame.DiceGame {
+	tdgmu.Lock()
+	defer tdgmu.Unlock()
+	return tdg
+}
+
+// play - switch the command line to another game
+func play(dg *dicegame.DiceGame) {
+	tdgmu.Lock()
+	defer tdgmu.Unlock()
+	tdg = dg
+}
+
+func defaultGame() *dicegame.DiceGame {
+	dg, err := games.Create("Game001", "Freddy", "Danny", "Smeck")
+	if err != nil {
+		panic(err)
+	}
+	return dg
+}
+
+// openGames - keep the games in dir, picking up any left there by an earlier
+// run. Reports whether the command line's game was one of them.
+func openGames(dir string) (bool, error) {
+	st, err := dicegame.NewFileStore(dir)
+	if err != nil {
+		return false, err
+	}
+	reg, err := dicegame.OpenRegistry(st)
+	if err != nil {
+		return false, err
+	}
+	games = reg
+	dg, resumed := games.Lookup("Game001")
+	if !resumed {
+		dg = defaultGame()
+	}
+	play(dg)
+	return resumed, nil
+}
+
 func joinem(argv []string) string {
 	fmt.Printf("joinem\n")
 	return strings.Join(argv, ", ")
 }
 
-func showhist(dg *dicegame.DiceGame, argv []string) (int, error) {
+func showlog(gp *dicegame.DiceGame, argv []string) (int, error) {
+	dg := gp.Snapshot()
+	fmt.Printf("Log of game: %s (%d actions)\n", dg.ID, len(dg.Log))
+	for _, a := range dg.Log {
+		fmt.Printf("%s\n", a)
+	}
+	return 1, nil
+}
+
+func showhist(gp *dicegame.DiceGame, argv []string) (int, error) {
+	dg := gp.Snapshot()
 	fmt.Printf("History of game: %s (%d turns)\n", dg.ID, len(dg.Turns))
 	for turnno := 0; turnno < len(dg.Turns)-1; turnno++ {
 		ct := dg.Turns[turnno]
-		fmt.Printf("%s rolled a %d ()\n", ct.Player, ct.Score)
+		if ct.Settled != nil {
+			fmt.Printf("%s rolled a %d (%v)\n", ct.Who(), ct.Score, *ct.Settled)
+		} else {
+			fmt.Printf("%s rolled a %d ()\n", ct.Who(), ct.Score)
+		}
 	}
 	// Show the current (last) turns
 	ct := dg.Turns[len(dg.Turns)-1]
 	fmt.Printf("Current: %s\n", ct.String())
+	for _, a := range dg.Corrections() {
+		fmt.Printf("Correction: %s\n", a)
+	}
+	return 1, nil
+}
+
+func showturns(gp *dicegame.DiceGame, argv []string) (int, error) {
+	dg := gp.Snapshot()
+	if len(argv) > 1 {
+		dt, err := diceturn.ParseWith(dg.Rules, strings.Join(argv[1:], " "))
+		if err != nil {
+			return 1, err
+		}
+		fmt.Printf("%s\n%v\n", diceturn.Format(dt), dt)
+		return 1, nil
+	}
+	for turnno, dt := range dg.Turns {
+		fmt.Printf("%3d %s\n", turnno+1, diceturn.Format(dt))
+	}
 	return 1, nil
 }
 
 func passto(dg *dicegame.DiceGame, argv []string) (int, error) {
 	if len(argv) < 2 {
-		return 1, fmt.Errorf("must specify a player")
+		s := dg.Snapshot()
+		fmt.Printf("%s can pass to: %s\n", s.CurPlayer(), strings.Join(s.PassTargets(), ", "))
+		return 1, nil
 	}
-	ret := dg.PassDice(argv[1])
-	if 0 != ret {
-		return 1, fmt.Errorf("FAIL! (%d)", ret)
+	st, err := dg.PassDice(argv[1])
+	if err != nil {
+		return 1, err
 	}
+	fmt.Printf("%v\n", st)
 	return 1, nil
 }
 
@@ -84,6 +189,21 @@ func rolldice(dg *dicegame.DiceGame, argv []string) (int, error) {
 	if len(argv) < 2 {
 		return 1, fmt.Errorf("ERROR: must specify dice values")
 	}
+	off := 0
+	if i := slices.Index(argv, "off"); i > 0 {
+		if i != len(argv)-2 {
+			return 1, fmt.Errorf("usage: roll <d0> <d1> <d2> off <offbits>")
+		}
+		omap, err := strconv.ParseInt(argv[i+1], 0, 32)
+		if err != nil {
+			return 1, fmt.Errorf("invalid off-table dicemap: %s", argv[i+1])
+		}
+		off = int(omap)
+		argv = argv[:i]
+	}
+	if len(argv) > 4 {
+		return 1, fmt.Errorf("ERROR: only three dice")
+	}
 	dice := []int{0, 0, 0}
 	for i := 1; i < len(argv); i++ {
 		if argv[i] == "-" {
@@ -100,37 +220,60 @@ func rolldice(dg *dicegame.DiceGame, argv []string) (int, error) {
 	}
 
 	fmt.Printf("Rolling %d/%d/%d\n", dice[0], dice[1], dice[2])
-	if err := dg.RollWith(dice[0], dice[1], dice[2]); err != nil {
+	rollreport(dg, dg.RollWithOff(off, dice[0], dice[1], dice[2]))
+	return 1, nil
+}
+
+func tossdice(dg *dicegame.DiceGame, argv []string) (int, error) {
+	if len(argv) < 2 || len(argv) > 3 {
+		return 1, fmt.Errorf("usage: toss <dicebits> [<offbits>]")
+	}
+	dmap, err := strconv.ParseInt(argv[1], 0, 32)
+	if err != nil {
+		return 1, fmt.Errorf("invalid dicemap specification: %s", argv[1])
+	}
+	var omap int64
+	if len(argv) > 2 {
+		if omap, err = strconv.ParseInt(argv[2], 0, 32); err != nil {
+			return 1, fmt.Errorf("invalid off-table dicemap: %s", argv[2])
+		}
+	}
+	rollreport(dg, dg.RollDiceOff(int(dmap), int(omap)))
+	return 1, nil
+}
+
+// rollreport - tell the roller how the roll went
+func rollreport(gp *dicegame.DiceGame, err error) {
+	dg := gp.Snapshot()
+	if err != nil {
 		fmt.Printf("Whoops - %v\n", err)
 	} else {
 		dt := dg.Turns[len(dg.Turns)-1]
 		cr := dt.Rolls[dt.NumRolls-1]
-		rv, special := cr.TurnValue()
+		rv, special := cr.TurnValueWith(dg.Rules)
 
+		if cr.OffTable != 0 {
+			fmt.Printf("OFF THE TABLE! (0b%03b rerolled)\n", cr.OffTable)
+		}
 		if cr.Consecs {
-			cs, err := dt.ConsecScore(dt.NumRolls)
-			if nil != err {
-				fmt.Printf("Error %s calculating consecutive score?\n", err.Error())
-			} else {
-				fmt.Printf("CONSECUTIVES! (%d)\n", cs)
-				// TODO: Add appropriate score
-			}
+			fmt.Printf("CONSECUTIVES!\n")
+		}
+		for _, m := range cr.Marks {
+			fmt.Printf("  %v\n", m)
 		}
 		if rv >= 0 || diceturn.NothingSpecial != special {
-			fmt.Printf("That's a %s\n", cr.TurnValueString())
+			fmt.Printf("That's a %s\n", cr.TurnValueStringWith(dg.Rules))
 
 		} else {
 			fmt.Printf("Whoops - value %d?\n", rv)
 		}
 	}
 
-	// TODO: Advance turn if this is third rolls
-	ct := dg.Turns[len(dg.Turns)-1]
-	if ct.NumRolls > 2 {
-		fmt.Printf("Turn over!\n")
+	// Three rolls and the turn's closed; all that's left is to pass the dice
+	if ct := dg.CurrentTurn(); ct.NumRolls > 2 && !dg.Over {
+		fmt.Printf("%s ends on %s (%s); pass the dice\n", ct.Who(), ct.RollString(),
+			ct.Rolls[ct.NumRolls-1].TurnValueStringWith(dg.Rules))
 	}
-
-	return 1, nil
 }
 
 func rollcheck(dg *dicegame.DiceGame, argv []string) (int, error) {
@@ -149,14 +292,350 @@ func rollcheck(dg *dicegame.DiceGame, argv []string) (int, error) {
 	return 1, nil
 }
 
+func hint(gp *dicegame.DiceGame, argv []string) (int, error) {
+	dg := gp.Snapshot()
+	choices, err := dg.Odds()
+	if err != nil {
+		return 1, err
+	}
+	score, special := diceodds.Target(dg.Rules, dg.PrevTurn())
+	fmt.Printf("%s, to beat %s:\n", dg.CurrentTurn().Who(), diceturn.ValueName(score, special))
+	for _, c := range choices {
+		fmt.Printf("  %v\n", c)
+	}
+	return 1, nil
+}
+
+func simulateturns(dg *dicegame.DiceGame, argv []string) (int, error) {
+	if len(argv) < 2 || len(argv) > 4 {
+		return 1, fmt.Errorf("usage: simulate <strategy>[,<strategy>...] [<turns>] [<seed>]")
+	}
+	cfg := simulate.Config{Turns: 1000000, Rules: dg.Snapshot().Rules}
+	if len(argv) > 2 {
+		n, err := strconv.Atoi(argv[2])
+		if err != nil {
+			return 1, fmt.Errorf("invalid number of turns: %s", argv[2])
+		}
+		cfg.Turns = n
+	}
+	if len(argv) > 3 {
+		seed, err := strconv.ParseInt(argv[3], 0, 64)
+		if err != nil {
+			return 1, fmt.Errorf("invalid seed: %s", argv[3])
+		}
+		cfg.Seed = seed
+	}
+	for _, name := range strings.Split(argv[1], ",") {
+		strat, err := simulate.StrategyNamed(name)
+		if err != nil {
+			return 1, err
+		}
+		start := time.Now()
+		res, err := simulate.Run(cfg, strat)
+		if err != nil {
+			return 1, err
+		}
+		fmt.Printf("%v  took %v\n", res, time.Since(start).Round(time.Millisecond))
+	}
+	return 1, nil
+}
+
+const tournamentUsage = "<strategy>,<strategy>[,...] [<games>] [<seed>] [rounds=<n>] [fixed] [rules=<file>] [csv=<file>]"
+
+func playtournament(dg *dicegame.DiceGame, argv []string) (int, error) {
+	if len(argv) < 2 {
+		return 1, fmt.Errorf("usage: tournament %s", tournamentUsage)
+	}
+	cfg := tournament.Config{Seats: strings.Split(argv[1], ","), Rotate: true, Games: 1000, Rounds: 1}
+	var rulesets []diceturn.Rules
+	csvfile := ""
+	nums := 0
+	for _, arg := range argv[2:] {
+		key, val := arg, ""
+		if i := strings.Index(arg, "="); i >= 0 {
+			key, val = arg[:i], arg[i+1:]
+		}
+		switch key {
+		case "fixed":
+			cfg.Rotate = false
+		case "rounds":
+			n, err := strconv.Atoi(val)
+			if err != nil {
+				return 1, fmt.Errorf("invalid number of rounds: %s", val)
+			}
+			cfg.Rounds = n
+		case "rules":
+			rules, err := diceturn.LoadRules(val)
+			if err != nil {
+				return 1, err
+			}
+			rulesets = append(rulesets, rules)
+		case "csv":
+			csvfile = val
+		default:
+			// The games, then the seed
+			n, err := strconv.ParseInt(arg, 0, 64)
+			if err != nil || nums > 1 {
+				return 1, fmt.Errorf("usage: tournament %s", tournamentUsage)
+			}
+			if nums == 0 {
+				cfg.Games = int(n)
+			} else {
+				cfg.Seed = n
+			}
+			nums++
+		}
+	}
+	if len(rulesets) == 0 {
+		rulesets = append(rulesets, dg.Snapshot().Rules)
+	}
+	// The same dice for every ruleset, so it's the rules that differ
+	if cfg.Seed == 0 {
+		cfg.Seed = time.Now().UnixNano()
+	}
+
+	var results []tournament.Result
+	for _, rules := range rulesets {
+		cfg.Rules = rules
+		start := time.Now()
+		res, err := tournament.Run(cfg)
+		if err != nil {
+			return 1, err
+		}
+		fmt.Printf("%v  took %v\n", res, time.Since(start).Round(time.Millisecond))
+		results = append(results, res)
+	}
+
+	switch csvfile {
+	case "":
+	case "-":
+		return 1, tournament.WriteCSV(os.Stdout, results...)
+	default:
+		f, err := os.Create(csvfile)
+		if err != nil {
+			return 1, err
+		}
+		err = tournament.WriteCSV(f, results...)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return 1, err
+		}
+		fmt.Printf("Wrote %s\n", csvfile)
+	}
+	return 1, nil
+}
+
 func showscore(dg *dicegame.DiceGame, argv []string) (int, error) {
-	fmt.Printf("Scorecard:\n%s", dg.Scorecard())
+	s := dg.Snapshot()
+	fmt.Printf("Scorecard:\n%s", s.Scorecard())
+	return 1, nil
+}
+
+// Stop watching the game being watched, if any
+var unwatch func()
+
+func watchgame(dg *dicegame.DiceGame, argv []string) (int, error) {
+	if unwatch != nil {
+		unwatch()
+		unwatch = nil
+		fmt.Printf("Stopped watching\n")
+		return 1, nil
+	}
+	evs, done := dg.Subscribe()
+	unwatch = done
+	go func() {
+		for ev := range evs {
+			fmt.Printf("[%s] %s\n", ev.Kind, ev.Text)
+		}
+	}()
+	fmt.Printf("Watching %s\n", dg.ID)
+	return 1, nil
+}
+
+func listgames(dg *dicegame.DiceGame, argv []string) (int, error) {
+	for _, id := range games.List() {
+		mark := " "
+		if id == dg.ID {
+			mark = "*"
+		}
+		gp, _ := games.Lookup(id)
+		fmt.Printf("%s %v\n", mark, gp.Snapshot())
+	}
+	if archived := games.ListArchived(); len(archived) > 0 {
+		fmt.Printf("Archived: %s\n", strings.Join(archived, ", "))
+	}
+	return 1, nil
+}
+
+func newgame(dg *dicegame.DiceGame, argv []string) (int, error) {
+	if len(argv) < 4 {
+		return 1, fmt.Errorf("usage: newgame <gameid> <p1>[:<bot>] <p2>[:<bot>] [<p3>[:<bot>] ...]")
+	}
+	// player:strategy seats a bot
+	var players []string
+	bots := map[string]string{}
+	for _, arg := range argv[2:] {
+		player, strategy := arg, ""
+		if i := strings.Index(arg, ":"); i >= 0 {
+			player, strategy = arg[:i], arg[i+1:]
+			if _, err := dicegame.StrategyNamed(strategy); err != nil {
+				return 1, err
+			}
+			bots[player] = strategy
+		}
+		players = append(players, player)
+	}
+	gp, err := games.Create(argv[1], players...)
+	if err != nil {
+		return 1, err
+	}
+	for _, player := range players {
+		if strategy, ok := bots[player]; ok {
+			if err := gp.SetBot(player, strategy); err != nil {
+				return 1, err
+			}
+		}
+	}
+	play(gp)
+	fmt.Printf("Now playing %v\n", gp.Snapshot())
+	return 1, nil
+}
+
+func setbot(dg *dicegame.DiceGame, argv []string) (int, error) {
+	if len(argv) < 2 || len(argv) > 3 {
+		return 1, fmt.Errorf("usage: bot <player> [<strategy>]")
+	}
+	strategy := ""
+	if len(argv) == 3 {
+		strategy = argv[2]
+	}
+	if err := dg.SetBot(argv[1], strategy); err != nil {
+		return 1, err
+	}
+	fmt.Printf("%v\n%s\n", dg.Snapshot(), dg.Snapshot().GameStatus())
+	return 1, nil
+}
+
+func addplayer(dg *dicegame.DiceGame, argv []string) (int, error) {
+	if len(argv) != 2 {
+		return 1, fmt.Errorf("usage: addplayer <player>")
+	}
+	if err := dg.AddPlayer(argv[1]); err != nil {
+		return 1, err
+	}
+	fmt.Printf("%v\n", dg.Snapshot())
+	return 1, nil
+}
+
+func removeplayer(dg *dicegame.DiceGame, argv []string) (int, error) {
+	if len(argv) != 2 {
+		return 1, fmt.Errorf("usage: removeplayer <player>")
+	}
+	if err := dg.RemovePlayer(argv[1]); err != nil {
+		return 1, err
+	}
+	fmt.Printf("%v\n", dg.Snapshot())
+	return 1, nil
+}
+
+func standin(dg *dicegame.DiceGame, argv []string) (int, error) {
+	if len(argv) > 2 {
+		return 1, fmt.Errorf("usage: standin [<roller>]")
+	}
+	roller := ""
+	if len(argv) == 2 {
+		roller = argv[1]
+	}
+	if err := dg.StandIn(roller); err != nil {
+		return 1, err
+	}
+	fmt.Printf("%s\n", dg.Snapshot().GameStatus())
+	return 1, nil
+}
+
+func usegame(dg *dicegame.DiceGame, argv []string) (int, error) {
+	if len(argv) != 2 {
+		return 1, fmt.Errorf("usage: usegame <gameid>")
+	}
+	gp, ok := games.Lookup(argv[1])
+	if !ok {
+		return 1, fmt.Errorf("no active game %s", argv[1])
+	}
+	play(gp)
+	fmt.Printf("Now playing %v\n", gp.Snapshot())
+	return 1, nil
+}
+
+func archivegame(dg *dicegame.DiceGame, argv []string) (int, error) {
+	if len(argv) != 2 {
+		return 1, fmt.Errorf("usage: archive <gameid>")
+	}
+	if argv[1] == playing().ID {
+		return 1, fmt.Errorf("can't archive the game being played; switch games first")
+	}
+	if err := games.Archive(argv[1]); err != nil {
+		return 1, err
+	}
+	fmt.Printf("Archived %s\n", argv[1])
+	return 1, nil
+}
+
+func newround(dg *dicegame.DiceGame, argv []string) (int, error) {
+	if err := dg.NewRound(); err != nil {
+		return 1, err
+	}
+	s := dg.Snapshot()
+	fmt.Printf("%s\n", s.GameStatus())
+	return 1, nil
+}
+
+func undo(dg *dicegame.DiceGame, argv []string) (int, error) {
+	if err := dg.Undo(); err != nil {
+		return 1, err
+	}
+	s := dg.Snapshot()
+	fmt.Printf("Undone. %s\n", s.CurTurn())
+	return 1, nil
+}
+
+func redo(dg *dicegame.DiceGame, argv []string) (int, error) {
+	if err := dg.Redo(); err != nil {
+		return 1, err
+	}
+	s := dg.Snapshot()
+	fmt.Printf("Redone. %s\n", s.CurTurn())
+	return 1, nil
+}
+
+func paychevron(dg *dicegame.DiceGame, argv []string) (int, error) {
+	if len(argv) != 3 {
+		return 1, fmt.Errorf("usage: pay <player> <chevron>")
+	}
+	cno, err := strconv.Atoi(argv[2])
+	if err != nil {
+		return 1, fmt.Errorf("invalid chevron number %s", argv[2])
+	}
+	if err := dg.PayChevron(argv[1], cno-1); err != nil {
+		return 1, err
+	}
+	fmt.Printf("%s paid for chevron %d\n", argv[1], cno)
+	return 1, nil
+}
+
+func showrules(dg *dicegame.DiceGame, argv []string) (int, error) {
+	fmt.Printf("%v\n", dg.Snapshot().Rules)
 	return 1, nil
 }
 
-func givestatus(dg *dicegame.DiceGame, argv []string) (int, error) {
-	fmt.Printf("Game: %v\n", *dg)
-	fmt.Printf("Turn: %s\n", dg.CurTurn())
+func givestatus(gp *dicegame.DiceGame, argv []string) (int, error) {
+	dg := gp.Snapshot()
+	fmt.Printf("Game: %v\n", dg)
+	fmt.Printf("%s\n", dg.GameStatus())
+	if !dg.Over {
+		fmt.Printf("Turn: %s\n", dg.CurTurn())
+	}
 	return 1, nil
 }
 
@@ -204,7 +683,7 @@ func runcmd(dg *dicegame.DiceGame, text string) int {
 	return 0
 }
 
-func interact(dg *dicegame.DiceGame) {
+func interact() {
 	reader := bufio.NewReader(os.Stdin)
 	fmt.Println("Talking shit?")
 	fmt.Println("-------------")
@@ -213,7 +692,7 @@ func interact(dg *dicegame.DiceGame) {
 		fmt.Print("3d% ")
 		text, _ := reader.ReadString('\n')
 
-		if 0 > runcmd(dg, text) {
+		if 0 > runcmd(playing(), text) {
 			break
 		}
 	}
@@ -226,6 +705,15 @@ func main() {
 		cmddoc = append(cmddoc, cmdhelp{c.command, c.argstr, c.usestr})
 	}
 
+	// 3dice <command> [<args>]: run the one command, simulate say, and quit
+	if len(os.Args) > 1 {
+		if _, err := dispatch(playing(), os.Args[1:]); err != nil {
+			fmt.Printf("Error with %s: %v\n", os.Args[1], err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	if err := setupRoutes(); err != nil {
 		fmt.Printf("ERROR settup up router: %v\n", err)
 	}
@@ -242,6 +730,31 @@ func main() {
 	// 	Players: []string{"Alpha", "Beta", "Greg"},
 	// }
 	//tdg := dicegame.NewGame("Game001", "Freddy", "Danny", "Smeck")
+	resumed := false
+	if dir := os.Getenv("3DICE_DATA"); dir != "" {
+		var err error
+		if resumed, err = openGames(dir); err != nil {
+			fmt.Printf("Not saving games to %s: %v\n", dir, err)
+		} else {
+			fmt.Printf("Saving games to %s; %d active\n", dir, len(games.List()))
+		}
+	}
+	if resumed {
+		fmt.Printf("Resuming %s: %s\n", tdg.ID, tdg.GameStatus())
+	} else if seed := os.Getenv("3DICE_SEED"); seed != "" {
+		if sv, err := strconv.ParseInt(seed, 0, 64); err != nil {
+			fmt.Printf("Ignoring bad 3DICE_SEED %s: %v\n", seed, err)
+		} else if err := tdg.SetDiceSource(dicegame.NewSeededSource(sv)); err != nil {
+			fmt.Printf("Seeding: %v\n", err)
+		}
+	}
+	if rfile := os.Getenv("3DICE_RULES"); rfile != "" {
+		if rules, err := diceturn.LoadRules(rfile); err != nil {
+			fmt.Printf("Ignoring rules: %v\n", err)
+		} else if err := tdg.SetRules(rules); err != nil {
+			fmt.Printf("Ignoring rules: %v\n", err)
+		}
+	}
 	fmt.Printf("Game: %v\n", tdg)
 
 	//tdg.Scores["Freddy"].Chevrons[0].Count = 11
@@ -278,9 +791,6 @@ func main() {
 	//   RollDice()
 	//   ChooseKeeps() or EndTurn()
 
-	// TODO: Handle someone rolling for someone else, like a community player
-	// stepping in for a roller; they roll the dice, the roller gets points
-
 	// TODO: Another screwy thing: some scorers allow a player to pick up a
 	// previously kept die after the second roll, to try and complete a triple
 	// 5 or 6. I'm *not* one of those scorers, unless they're rolling two dice
@@ -314,6 +824,6 @@ func main() {
 		fmt.Printf("ERROR: %v\n", weberr)
 	}
 
-	// interact(&tdg)
+	// interact()
 	// tdg.RollDice()
 }
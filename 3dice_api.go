@@ -0,0 +1,417 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"golang.org/x/exp/slices"
+
+	"wojones.com/src/dicegame"
+	"wojones.com/src/diceodds"
+	"wojones.com/src/diceturn"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// The JSON API: /api/games/...
+
+type apiError struct {
+	Status int    `json:"status"`
+	Error  string `json:"error"`
+}
+
+type newGameReq struct {
+	ID      string            `json:"game_id"`
+	Players []string          `json:"players"`
+	Seed    *int64            `json:"seed,omitempty"`
+	Rules   *diceturn.Rules   `json:"rules,omitempty"`
+	Bots    map[string]string `json:"bots,omitempty"` // player -> strategy
+}
+
+type rollReq struct {
+	Dice   *[3]int `json:"dice,omitempty"`   // values to roll with, 0 for a kept die
+	ToRoll int     `json:"toroll,omitempty"` // or: bitmap of dice to roll with the dice source
+	Off    int     `json:"off,omitempty"`    // bitmap of dice that left the table
+}
+
+type rollCheckReq struct {
+	ToRoll int `json:"toroll"`
+}
+
+type rollCheckResp struct {
+	ToRoll int  `json:"toroll"`
+	OK     bool `json:"ok"`
+}
+
+type passReq struct {
+	Player string `json:"player"`
+}
+
+type playerReq struct {
+	Player string `json:"player"`
+}
+
+type standInReq struct {
+	Roller string `json:"roller"` // empty if nobody's standing in
+}
+
+type hintResp struct {
+	Player  string            `json:"player"`
+	Against string            `json:"against"`
+	Choices []diceodds.Choice `json:"choices"`
+}
+
+type passResp struct {
+	Settlement diceturn.Settlement `json:"settlement"`
+	Game       *dicegame.DiceGame  `json:"game"`
+}
+
+type apiCtxKey string
+
+const apiGameKey apiCtxKey = "apigame"
+
+func setupAPIRoutes(r chi.Router) {
+	r.Route("/api/games", func(r chi.Router) {
+		r.Get("/", apiListGames)
+		r.Post("/", apiCreateGame)
+		r.Route("/{gameID}", func(r chi.Router) {
+			r.Use(apiGameCtx)
+			r.Get("/", apiGetGame)
+			r.Get("/history", apiHistory)
+			r.Get("/log", apiLog)
+			r.Get("/events", apiEvents)
+			r.Get("/hint", apiHint)
+			r.Post("/roll", apiRoll)
+			r.Post("/rollcheck", apiRollCheck)
+			r.Get("/pass", apiPassTargets)
+			r.Post("/pass", apiPass)
+			r.Post("/players", apiAddPlayer)
+			r.Delete("/players/{player}", apiRemovePlayer)
+			r.Post("/standin", apiStandIn)
+			r.Post("/undo", apiUndo)
+			r.Post("/redo", apiRedo)
+		})
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		fmt.Printf("writeJSON: %v\n", err)
+	}
+}
+
+// writeGame - send a snapshot of the game
+func writeGame(w http.ResponseWriter, status int, dg *dicegame.DiceGame) {
+	s := dg.Snapshot()
+	writeJSON(w, status, &s)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	// Whatever was asked, a game that couldn't be saved or a bot that
+	// couldn't play is our fault
+	if dicegame.IsSaveError(err) || dicegame.IsBotError(err) {
+		status = http.StatusInternalServerError
+	}
+	writeJSON(w, status, apiError{Status: status, Error: err.Error()})
+}
+
+// playError - an error from playing the game: the round being over is a
+// conflict, anything else is a move the rules don't allow
+func playError(w http.ResponseWriter, err error) {
+	if dicegame.IsGameOver(err) {
+		writeError(w, http.StatusConflict, err)
+	} else {
+		writeError(w, http.StatusUnprocessableEntity, err)
+	}
+}
+
+func decodeBody(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("bad request body: %v", err))
+		return false
+	}
+	return true
+}
+
+// apiGameCtx - resolve {gameID} to an active game; archived games can only
+// be looked at
+func apiGameCtx(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gameID := chi.URLParam(r, "gameID")
+		game, ok := games.Lookup(gameID)
+		if !ok && r.Method == http.MethodGet {
+			game, ok = games.LookupArchived(gameID)
+		}
+		if !ok {
+			if _, archived := games.LookupArchived(gameID); archived {
+				writeError(w, http.StatusConflict, fmt.Errorf("game %s is archived", gameID))
+			} else {
+				writeError(w, http.StatusNotFound, fmt.Errorf("no game %s", gameID))
+			}
+			return
+		}
+		ctx := context.WithValue(r.Context(), apiGameKey, game)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func apiGameFrom(r *http.Request) *dicegame.DiceGame {
+	return r.Context().Value(apiGameKey).(*dicegame.DiceGame)
+}
+
+func apiListGames(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string][]string{
+		"games": games.List(), "archived": games.ListArchived()})
+}
+
+func apiCreateGame(w http.ResponseWriter, r *http.Request) {
+	var req newGameReq
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if req.ID == "" {
+		req.ID = nextGameID()
+	}
+
+	dg, err := dicegame.NewGame(req.ID, req.Players...)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.Seed != nil {
+		if err := dg.SetDiceSource(dicegame.NewSeededSource(*req.Seed)); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	if req.Rules != nil {
+		if err := dg.SetRules(*req.Rules); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	for player, strategy := range req.Bots {
+		if !slices.Contains(req.Players, player) {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("bot %s isn't playing", player))
+			return
+		}
+		if _, err := dicegame.StrategyNamed(strategy); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	if err := games.Add(dg); err != nil {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	// Seat the bots in order round the table, in case the first one plays
+	// straight away
+	for _, player := range req.Players {
+		if strategy, ok := req.Bots[player]; ok {
+			if err := dg.SetBot(player, strategy); err != nil {
+				writeError(w, http.StatusBadRequest, err)
+				return
+			}
+		}
+	}
+	writeGame(w, http.StatusCreated, dg)
+}
+
+// nextGameID - the first GameNNN not already taken
+func nextGameID() string {
+	for n := 1; ; n++ {
+		id := fmt.Sprintf("Game%03d", n)
+		_, active := games.Lookup(id)
+		_, archived := games.LookupArchived(id)
+		if !active && !archived {
+			return id
+		}
+	}
+}
+
+func apiGetGame(w http.ResponseWriter, r *http.Request) {
+	writeGame(w, http.StatusOK, apiGameFrom(r))
+}
+
+func apiHistory(w http.ResponseWriter, r *http.Request) {
+	dg := apiGameFrom(r).Snapshot()
+	writeJSON(w, http.StatusOK, map[string]interface{}{"game_id": dg.ID, "turns": dg.Turns})
+}
+
+func apiLog(w http.ResponseWriter, r *http.Request) {
+	dg := apiGameFrom(r).Snapshot()
+	writeJSON(w, http.StatusOK, map[string]interface{}{"game_id": dg.ID, "log": dg.Log})
+}
+
+// apiHint - the odds of each move open to the roller, best first
+func apiHint(w http.ResponseWriter, r *http.Request) {
+	dg := apiGameFrom(r).Snapshot()
+	choices, err := dg.Odds()
+	if err != nil {
+		playError(w, err)
+		return
+	}
+	score, special := diceodds.Target(dg.Rules, dg.PrevTurn())
+	writeJSON(w, http.StatusOK, hintResp{Player: dg.CurPlayer(), Against: diceturn.ValueName(score, special),
+		Choices: choices})
+}
+
+// apiEvents - stream the game's events to the client as Server-Sent Events,
+// each one a JSON dicegame.Event
+func apiEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("streaming not supported"))
+		return
+	}
+	dg := apiGameFrom(r)
+	evs, done := dg.Subscribe()
+	defer done()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, ": following game %s\n\n", dg.ID)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case ev, ok := <-evs:
+			if !ok {
+				return
+			}
+			buf, err := json.Marshal(ev)
+			if err != nil {
+				fmt.Printf("apiEvents: %v\n", err)
+				continue
+			}
+			fmt.Fprintf(w, "data: %s\n\n", buf)
+			flusher.Flush()
+		}
+	}
+}
+
+func apiRoll(w http.ResponseWriter, r *http.Request) {
+	var req rollReq
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	dg := apiGameFrom(r)
+
+	var err error
+	switch {
+	case req.Dice != nil && req.ToRoll != 0:
+		writeError(w, http.StatusBadRequest, fmt.Errorf("give either dice or toroll, not both"))
+		return
+	case req.Dice != nil:
+		for _, d := range req.Dice {
+			if d < 0 || d > 6 {
+				writeError(w, http.StatusBadRequest, fmt.Errorf("invalid value for a die: %d", d))
+				return
+			}
+		}
+		err = dg.RollWithOff(req.Off, req.Dice[0], req.Dice[1], req.Dice[2])
+	default:
+		err = dg.RollDiceOff(req.ToRoll, req.Off)
+	}
+	if err != nil {
+		playError(w, err)
+		return
+	}
+	writeGame(w, http.StatusOK, dg)
+}
+
+func apiRollCheck(w http.ResponseWriter, r *http.Request) {
+	var req rollCheckReq
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if err := apiGameFrom(r).RollCheck(req.ToRoll); err != nil {
+		playError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, rollCheckResp{ToRoll: req.ToRoll, OK: true})
+}
+
+// apiPassTargets - who the current roller can pass the dice to
+func apiPassTargets(w http.ResponseWriter, r *http.Request) {
+	dg := apiGameFrom(r).Snapshot()
+	writeJSON(w, http.StatusOK, map[string]interface{}{"player": dg.CurPlayer(), "targets": dg.PassTargets()})
+}
+
+func apiPass(w http.ResponseWriter, r *http.Request) {
+	var req passReq
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	dg := apiGameFrom(r)
+	st, err := dg.PassDice(req.Player)
+	if err != nil {
+		playError(w, err)
+		return
+	}
+	s := dg.Snapshot()
+	writeJSON(w, http.StatusOK, passResp{Settlement: st, Game: &s})
+}
+
+func apiAddPlayer(w http.ResponseWriter, r *http.Request) {
+	var req playerReq
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	dg := apiGameFrom(r)
+	if err := dg.AddPlayer(req.Player); err != nil {
+		playError(w, err)
+		return
+	}
+	writeGame(w, http.StatusOK, dg)
+}
+
+func apiRemovePlayer(w http.ResponseWriter, r *http.Request) {
+	dg := apiGameFrom(r)
+	if err := dg.RemovePlayer(chi.URLParam(r, "player")); err != nil {
+		playError(w, err)
+		return
+	}
+	writeGame(w, http.StatusOK, dg)
+}
+
+func apiStandIn(w http.ResponseWriter, r *http.Request) {
+	var req standInReq
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	dg := apiGameFrom(r)
+	if err := dg.StandIn(req.Roller); err != nil {
+		playError(w, err)
+		return
+	}
+	writeGame(w, http.StatusOK, dg)
+}
+
+func apiUndo(w http.ResponseWriter, r *http.Request) {
+	dg := apiGameFrom(r)
+	if err := dg.Undo(); err != nil {
+		playError(w, err)
+		return
+	}
+	writeGame(w, http.StatusOK, dg)
+}
+
+func apiRedo(w http.ResponseWriter, r *http.Request) {
+	dg := apiGameFrom(r)
+	if err := dg.Redo(); err != nil {
+		playError(w, err)
+		return
+	}
+	writeGame(w, http.StatusOK, dg)
+}
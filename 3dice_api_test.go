@@ -0,0 +1,291 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"wojones.com/src/dicegame"
+)
+
+func apiDo(t *testing.T, method string, path string, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+	return rec
+}
+
+func Test_api(t *testing.T) {
+	if err := setupRoutes(); err != nil {
+		t.Fatalf("setupRoutes: %v", err)
+	}
+
+	rec := apiDo(t, http.MethodPost, "/api/games/",
+		`{"game_id": "ApiGame", "players": ["Ann", "Bob", "Cat"], "seed": 5}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create: %d %s", rec.Code, rec.Body.String())
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		want   int
+	}{
+		{"duplicate game", http.MethodPost, "/api/games/", `{"game_id": "ApiGame", "players": ["A", "B", "C"]}`, http.StatusConflict},
+		{"bot not playing", http.MethodPost, "/api/games/", `{"players": ["A", "B"], "bots": {"C": "greedy-low"}}`, http.StatusBadRequest},
+		{"bot with no strategy", http.MethodPost, "/api/games/", `{"players": ["A", "B"], "bots": {"B": "cheater"}}`, http.StatusBadRequest},
+		{"bots", http.MethodPost, "/api/games/", `{"game_id": "BotGame", "players": ["A", "B", "C"], "bots": {"A": "odds-optimal", "C": "triple-chaser"}, "seed": 1}`, http.StatusCreated},
+		{"misspelt rule", http.MethodPost, "/api/games/", `{"players": ["A", "B"], "rules": {"chevron_sz": 15}}`, http.StatusBadRequest},
+		{"one player", http.MethodPost, "/api/games/", `{"players": ["A"]}`, http.StatusBadRequest},
+		{"same player twice", http.MethodPost, "/api/games/", `{"players": ["A", "B", "A"]}`, http.StatusBadRequest},
+		{"unknown game", http.MethodGet, "/api/games/Nope/", "", http.StatusNotFound},
+		{"bad body", http.MethodPost, "/api/games/ApiGame/roll", `{"dice": "x"}`, http.StatusBadRequest},
+		{"partial first roll", http.MethodPost, "/api/games/ApiGame/rollcheck", `{"toroll": 1}`, http.StatusUnprocessableEntity},
+		{"full first roll", http.MethodPost, "/api/games/ApiGame/rollcheck", `{"toroll": 7}`, http.StatusOK},
+		{"pass before rolling", http.MethodPost, "/api/games/ApiGame/pass", `{"player": "Bob"}`, http.StatusUnprocessableEntity},
+		{"roll", http.MethodPost, "/api/games/ApiGame/roll", `{"dice": [1, 2, 4]}`, http.StatusOK},
+		{"roll a kept die", http.MethodPost, "/api/games/ApiGame/roll", `{"dice": [0, 0, 0]}`, http.StatusUnprocessableEntity},
+		{"roll from source", http.MethodPost, "/api/games/ApiGame/roll", `{"toroll": 6}`, http.StatusOK},
+		{"stand in mid-turn", http.MethodPost, "/api/games/ApiGame/standin", `{"roller": "Zed"}`, http.StatusUnprocessableEntity},
+		{"hint", http.MethodGet, "/api/games/ApiGame/hint", "", http.StatusOK},
+		{"pass targets", http.MethodGet, "/api/games/ApiGame/pass", "", http.StatusOK},
+		{"pass to stranger", http.MethodPost, "/api/games/ApiGame/pass", `{"player": "Dan"}`, http.StatusUnprocessableEntity},
+		{"pass", http.MethodPost, "/api/games/ApiGame/pass", `{"player": "Bob"}`, http.StatusOK},
+		{"undo", http.MethodPost, "/api/games/ApiGame/undo", "", http.StatusOK},
+		{"redo", http.MethodPost, "/api/games/ApiGame/redo", "", http.StatusOK},
+		{"nothing to redo", http.MethodPost, "/api/games/ApiGame/redo", "", http.StatusUnprocessableEntity},
+		{"stand in", http.MethodPost, "/api/games/ApiGame/standin", `{"roller": "Zed"}`, http.StatusOK},
+		{"add player", http.MethodPost, "/api/games/ApiGame/players", `{"player": "Dan"}`, http.StatusOK},
+		{"add player twice", http.MethodPost, "/api/games/ApiGame/players", `{"player": "Dan"}`, http.StatusUnprocessableEntity},
+		{"remove roller", http.MethodDelete, "/api/games/ApiGame/players/Bob", "", http.StatusUnprocessableEntity},
+		{"remove player", http.MethodDelete, "/api/games/ApiGame/players/Dan", "", http.StatusOK},
+		{"game", http.MethodGet, "/api/games/ApiGame/", "", http.StatusOK},
+		{"history", http.MethodGet, "/api/games/ApiGame/history", "", http.StatusOK},
+		{"log", http.MethodGet, "/api/games/ApiGame/log", "", http.StatusOK},
+		{"list", http.MethodGet, "/api/games/", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := apiDo(t, tt.method, tt.path, tt.body)
+			if rec.Code != tt.want {
+				t.Errorf("%s %s = %d, want %d (%s)", tt.method, tt.path, rec.Code, tt.want, rec.Body.String())
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("%s %s content type %s", tt.method, tt.path, ct)
+			}
+			if rec.Code >= 400 {
+				var ae apiError
+				if err := json.Unmarshal(rec.Body.Bytes(), &ae); err != nil || ae.Error == "" || ae.Status != rec.Code {
+					t.Errorf("Unstructured error body: %s", rec.Body.String())
+				}
+			}
+		})
+	}
+
+	var hist struct {
+		Turns []json.RawMessage `json:"turns"`
+	}
+	rec = apiDo(t, http.MethodGet, "/api/games/ApiGame/history", "")
+	if err := json.Unmarshal(rec.Body.Bytes(), &hist); err != nil || len(hist.Turns) != 2 {
+		t.Errorf("History should have two turns: %s", rec.Body.String())
+	}
+
+	// The log replays to the same game
+	var log struct {
+		Log []dicegame.Action `json:"log"`
+	}
+	rec = apiDo(t, http.MethodGet, "/api/games/ApiGame/log", "")
+	if err := json.Unmarshal(rec.Body.Bytes(), &log); err != nil {
+		t.Fatalf("Bad log: %v", err)
+	}
+	back, err := dicegame.Replay(log.Log)
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	dg, _ := games.Lookup("ApiGame")
+	if back.GameStatus() != dg.GameStatus() || len(back.Turns) != len(dg.Turns) {
+		t.Errorf("Replayed log gave %s, expected %s", back.GameStatus(), dg.GameStatus())
+	}
+}
+
+// downStore - a store that stops saving when it's down
+type downStore struct {
+	down bool
+}
+
+func (ds *downStore) Save(dg *dicegame.DiceGame) error {
+	if ds.down {
+		return os.ErrPermission
+	}
+	return nil
+}
+func (ds *downStore) Load(ID string, archived bool) (*dicegame.DiceGame, error) {
+	return nil, os.ErrNotExist
+}
+func (ds *downStore) List(archived bool) ([]string, error) { return nil, nil }
+func (ds *downStore) Archive(ID string) error              { return nil }
+
+func Test_apiSaveError(t *testing.T) {
+	if err := setupRoutes(); err != nil {
+		t.Fatalf("setupRoutes: %v", err)
+	}
+	ds := &downStore{}
+	reg, err := dicegame.OpenRegistry(ds)
+	if err != nil {
+		t.Fatalf("OpenRegistry: %v", err)
+	}
+	saved := games
+	games = reg
+	defer func() { games = saved }()
+
+	rec := apiDo(t, http.MethodPost, "/api/games/", `{"game_id": "Unsaved", "players": ["Ann", "Bob"]}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create: %d %s", rec.Code, rec.Body.String())
+	}
+	ds.down = true
+	if rec := apiDo(t, http.MethodPost, "/api/games/Unsaved/roll", `{"dice": [1, 2, 4]}`); rec.Code != http.StatusInternalServerError {
+		t.Errorf("Roll that couldn't be saved: %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := apiDo(t, http.MethodPost, "/api/games/", `{"game_id": "Unsaved2", "players": ["Ann", "Bob"]}`); rec.Code != http.StatusInternalServerError {
+		t.Errorf("Create that couldn't be saved: %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func Test_apiEvents(t *testing.T) {
+	if err := setupRoutes(); err != nil {
+		t.Fatalf("setupRoutes: %v", err)
+	}
+	gp, err := games.Create("SseGame", "Ann", "Bob", "Cat")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	srv := httptest.NewServer(router)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/api/games/" + gp.ID + "/events")
+	if err != nil {
+		t.Fatalf("GET events: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content type %s", ct)
+	}
+	rd := bufio.NewReader(resp.Body)
+	// Wait for the subscription before rolling
+	if line, err := rd.ReadString('\n'); err != nil || !strings.HasPrefix(line, ":") {
+		t.Fatalf("No stream preamble (%q, %v)", line, err)
+	}
+
+	rr, err := http.Post(srv.URL+"/api/games/"+gp.ID+"/roll", "application/json",
+		strings.NewReader(`{"dice": [1, 2, 4]}`))
+	if err != nil || rr.StatusCode != http.StatusOK {
+		t.Fatalf("POST roll: %v %v", rr, err)
+	}
+	rr.Body.Close()
+
+	for {
+		line, err := rd.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Reading events: %v", err)
+		}
+		if line = strings.TrimSpace(line); strings.HasPrefix(line, "data: ") {
+			data := strings.TrimPrefix(line, "data: ")
+			var ev dicegame.Event
+			if err := json.Unmarshal([]byte(data), &ev); err != nil {
+				t.Fatalf("Bad event %s: %v", data, err)
+			}
+			if ev.Kind != dicegame.EventRoll || ev.Player != "Ann" {
+				t.Errorf("Expected Ann's roll, got %v", ev)
+			}
+			return
+		}
+	}
+}
+
+// Test_apiConcurrent - play one game from lots of clients at once, through
+// the API and the /play form, while others read it. Run with -race.
+func Test_apiConcurrent(t *testing.T) {
+	if err := setupRoutes(); err != nil {
+		t.Fatalf("setupRoutes: %v", err)
+	}
+	gp, err := games.Create("RaceGame", "Ann", "Bob", "Cat")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	gp.SetDiceSource(dicegame.NewSeededSource(9))
+	was := playing()
+	play(gp)
+	defer play(was)
+	evs, done := gp.Subscribe()
+	defer done()
+	go func() {
+		for range evs {
+		}
+	}()
+	srv := httptest.NewServer(router)
+	defer srv.Close()
+	api := srv.URL + "/api/games/" + gp.ID
+
+	players := []string{"Ann", "Bob", "Cat"}
+	var wg sync.WaitGroup
+	for c := 0; c < 8; c++ {
+		wg.Add(1)
+		go func(c int) {
+			defer wg.Done()
+			for i := 0; i < 25; i++ {
+				var resp *http.Response
+				var err error
+				switch (c + i) % 8 {
+				case 0, 1:
+					resp, err = http.Post(api+"/roll", "application/json",
+						strings.NewReader(fmt.Sprintf(`{"toroll": %d}`, 1+(c*i)%7)))
+				case 2:
+					resp, err = http.Post(api+"/pass", "application/json",
+						strings.NewReader(fmt.Sprintf(`{"player": "%s"}`, players[(c+i)%3])))
+				case 3:
+					resp, err = http.Get(api + "/")
+				case 4:
+					resp, err = http.Get(srv.URL + "/games/" + gp.ID + "/")
+				case 5:
+					resp, err = http.PostForm(srv.URL+"/play", url.Values{"move": {"toss 7"}})
+				case 6:
+					resp, err = http.PostForm(srv.URL+"/play", url.Values{"move": {"newround"}})
+				case 7:
+					resp, err = http.Post(api+"/undo", "application/json", nil)
+				}
+				if err != nil {
+					t.Errorf("Client %d: %v", c, err)
+					return
+				}
+				resp.Body.Close()
+				switch resp.StatusCode {
+				case http.StatusOK, http.StatusConflict, http.StatusUnprocessableEntity:
+				default:
+					t.Errorf("Client %d: %s %s", c, resp.Request.URL, resp.Status)
+				}
+			}
+		}(c)
+	}
+	wg.Wait()
+
+	// However it went, the log still adds up to the game
+	want := gp.Snapshot()
+	back, err := dicegame.Replay(want.Log)
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	wbuf, _ := json.Marshal(&want)
+	gbuf, _ := json.Marshal(back)
+	if string(gbuf) != string(wbuf) {
+		t.Errorf("Replayed game differs:\n%s\nexpected:\n%s", gbuf, wbuf)
+	}
+}
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"wojones.com/src/dicegame"
@@ -32,3 +34,26 @@ func Test_rollcheck(t *testing.T) {
 		})
 	}
 }
+
+func Test_gameRoutes(t *testing.T) {
+	if err := setupRoutes(); err != nil {
+		t.Fatalf("setupRoutes: %v", err)
+	}
+	tests := []struct {
+		path string
+		want int
+	}{
+		{"/games/" + tdg.ID + "/", http.StatusOK},
+		{"/games/NoSuchGame/", http.StatusNotFound},
+		{"/games/", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != tt.want {
+				t.Errorf("GET %s = %d, want %d", tt.path, rec.Code, tt.want)
+			}
+		})
+	}
+}
@@ -24,7 +24,10 @@ func setupRoutes() error {
 	router.Use(middleware.Recoverer)
 
 	router.Get("/", indexHandler)
+	router.Post("/play", searchHandler)
+	setupAPIRoutes(router)
 	router.Route("/games", func(r chi.Router) {
+		r.Get("/", listGames)
 		r.Route("/{gameID}", func(r chi.Router) {
 			r.Use(gameCtx)
 			r.Get("/", getGame)
@@ -49,16 +52,26 @@ func webListen() error {
 	return err
 }
 
-func parseargs() pongo2.Context {
-	return pongo2.Context{"name": "jack", "dicegame": tdg, "start": starttime.Format(time.DateTime)}
+// parseargs - the template context for a game: a snapshot of it, so the page
+// shows one moment of the game even as it's being played
+func parseargs(gp *dicegame.DiceGame) pongo2.Context {
+	dg := gp.Snapshot()
+	return pongo2.Context{"name": "jack", "dicegame": &dg, "start": starttime.Format(time.DateTime),
+		"games": games.List()}
 }
 
 func gameCtx(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		gameID := chi.URLParam(r, "gameID")
 		fmt.Printf("gameCtx: game %s\n", gameID)
-		// FOR NOW: There's only one game ...
-		game := &tdg
+		game, ok := games.Lookup(gameID)
+		if !ok {
+			game, ok = games.LookupArchived(gameID)
+		}
+		if !ok {
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
 		ctx := context.WithValue(r.Context(), "game", game)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
@@ -74,7 +87,15 @@ func getGame(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	fmt.Printf("getGame: got game %s\n", gp.ID)
-	e_err := ptpl.ExecuteWriter(parseargs(), w)
+	e_err := ptpl.ExecuteWriter(parseargs(gp), w)
+	if e_err != nil {
+		http.Error(w, e_err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func listGames(w http.ResponseWriter, r *http.Request) {
+	fmt.Printf("listGames: %s\n", r.URL.String())
+	e_err := ptpl.ExecuteWriter(parseargs(playing()), w)
 	if e_err != nil {
 		http.Error(w, e_err.Error(), http.StatusInternalServerError)
 	}
@@ -94,7 +115,7 @@ func gamesHandler(w http.ResponseWriter, r *http.Request) {
 func indexHandler(w http.ResponseWriter, r *http.Request) {
 	fmt.Printf("Sending index.html ...\n")
 	fmt.Printf("Handling index (url: %s)\n", r.URL.String())
-	err := ptpl.ExecuteWriter(parseargs(), w)
+	err := ptpl.ExecuteWriter(parseargs(playing()), w)
 	fmt.Printf("THWACK! Writer executed!\n")
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -136,10 +157,10 @@ func searchHandler(w http.ResponseWriter, r *http.Request) {
 	fmt.Println("Command is: ", command)
 	fmt.Println("Page is: ", page)
 
-	runcmd(&tdg, command)
+	runcmd(playing(), command)
 	//tpl.Execute(w, tdg)
 
-	e_err := ptpl.ExecuteWriter(parseargs(), w)
+	e_err := ptpl.ExecuteWriter(parseargs(playing()), w)
 	fmt.Printf("Written!\n")
 	if e_err != nil {
 		http.Error(w, e_err.Error(), http.StatusInternalServerError)
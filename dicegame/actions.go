@@ -0,0 +1,231 @@
+package dicegame
+
+import (
+	"fmt"
+	"time"
+
+	"wojones.com/src/diceturn"
+)
+
+// ActionKind - what a logged Action did
+type ActionKind string
+
+const (
+	ActNewGame  ActionKind = "new_game"
+	ActRules    ActionKind = "rules"
+	ActSource   ActionKind = "dice_source"
+	ActRoll     ActionKind = "roll"
+	ActPass     ActionKind = "pass"
+	ActNewRound ActionKind = "new_round"
+	ActPay      ActionKind = "pay"
+	ActUndo     ActionKind = "undo"
+	ActRedo     ActionKind = "redo"
+
+	ActAddPlayer    ActionKind = "add_player"
+	ActRemovePlayer ActionKind = "remove_player"
+	ActStandIn      ActionKind = "stand_in"
+	ActBot          ActionKind = "bot"
+
+	ActSnapshot ActionKind = "snapshot"
+)
+
+// Action - one thing done to a game, as recorded in its log. The log is
+// append-only, and replaying it from the start rebuilds the game exactly.
+// Which fields matter depends on Kind.
+type Action struct {
+	Seq  int        `json:"seq"`
+	Kind ActionKind `json:"kind"`
+	When time.Time  `json:"when"`
+
+	// ActNewGame
+	GameID  string   `json:"game_id,omitempty"`
+	Players []string `json:"players,omitempty"`
+
+	// ActRules
+	Rules *diceturn.Rules `json:"rules,omitempty"`
+
+	// ActSource
+	Source string `json:"source,omitempty"`
+	Seed   int64  `json:"seed,omitempty"`
+
+	// ActRoll: the values rolled (0 for a kept die), the dice that left the
+	// table and what they were rerolled to, and how many dice were drawn from
+	// the game's dice source along the way
+	Dice    [3]int `json:"dice,omitempty"`
+	Off     int    `json:"off,omitempty"`
+	Rerolls [3]int `json:"rerolls,omitempty"`
+	Draws   int    `json:"draws,omitempty"`
+
+	// ActPass, ActPay, ActAddPlayer, ActRemovePlayer, ActStandIn (the
+	// stand-in, or empty if the player takes their dice back)
+	Player  string `json:"player,omitempty"`
+	Chevron int    `json:"chevron,omitempty"`
+
+	// ActBot: the strategy the computer plays Player's seat by, or empty if
+	// a person takes the seat back
+	Bot string `json:"bot,omitempty"`
+
+	// ActUndo, ActRedo: the Seq of the action undone or redone
+	Ref int `json:"ref,omitempty"`
+
+	// ActSnapshot: the game as it stood when its log started, for a game
+	// played before there were logs. Replay picks up from here.
+	Game *DiceGame `json:"game,omitempty"`
+}
+
+func (a Action) String() string {
+	s := fmt.Sprintf("#%d %s", a.Seq, a.Kind)
+	switch a.Kind {
+	case ActNewGame:
+		s += fmt.Sprintf(" %s %v", a.GameID, a.Players)
+	case ActRules:
+		s += fmt.Sprintf(" %s", a.Rules.Name)
+	case ActSource:
+		s += fmt.Sprintf(" %s/%d", a.Source, a.Seed)
+	case ActRoll:
+		s += fmt.Sprintf(" %v", a.Dice)
+		if a.Off != 0 {
+			s += fmt.Sprintf(" off 0b%03b -> %v", a.Off, a.Rerolls)
+		}
+	case ActPass:
+		s += " to " + a.Player
+	case ActPay:
+		s += fmt.Sprintf(" %s chevron %d", a.Player, a.Chevron+1)
+	case ActAddPlayer, ActRemovePlayer:
+		s += " " + a.Player
+	case ActBot:
+		s += fmt.Sprintf(" %s %s", a.Player, a.Bot)
+	case ActStandIn:
+		if a.Player == "" {
+			s += " none"
+		} else {
+			s += " " + a.Player
+		}
+	case ActUndo, ActRedo:
+		s += fmt.Sprintf(" #%d", a.Ref)
+	case ActSnapshot:
+		if a.Game != nil {
+			s += fmt.Sprintf(" round %d, %d turns", a.Game.Round, len(a.Game.Turns))
+		}
+	}
+	return s
+}
+
+// record - append an action to the game's log, and save the game if it's
+// kept in a store. A failed save is remembered, for saved to report, until
+// the next one.
+func (dg *DiceGame) record(a Action) {
+	a.Seq = len(dg.Log) + 1
+	a.When = time.Now()
+	dg.Log = append(dg.Log, a)
+	if dg.store != nil {
+		dg.saveErr = dg.store.Save(dg)
+	}
+}
+
+// saved - err, if what was done to the game failed; otherwise a SaveError if
+// the game couldn't be saved the last time it was
+func (dg *DiceGame) saved(err error) error {
+	if err == nil && dg.saveErr != nil {
+		return &SaveError{ID: dg.ID, Err: dg.saveErr}
+	}
+	return err
+}
+
+// apply - redo a logged action, using its recorded dice rather than the
+// game's dice source
+func (dg *DiceGame) apply(a Action) error {
+	switch a.Kind {
+	case ActRules:
+		if a.Rules == nil {
+			return fmt.Errorf("rules action has no rules")
+		}
+		return dg.setRules(*a.Rules)
+	case ActSource:
+		src, err := sourceFor(a.Source, a.Seed, 0)
+		if err != nil {
+			return err
+		}
+		dg.setDiceSource(src)
+	case ActRoll:
+		if err := dg.rollWith(&a); err != nil {
+			return err
+		}
+		dg.Draws += a.Draws
+	case ActPass:
+		_, err := dg.passDice(a.Player)
+		return err
+	case ActNewRound:
+		return dg.newRound()
+	case ActPay:
+		return dg.payChevron(a.Player, a.Chevron)
+	case ActAddPlayer:
+		return dg.addPlayer(a.Player)
+	case ActRemovePlayer:
+		return dg.removePlayer(a.Player)
+	case ActStandIn:
+		return dg.standIn(a.Player)
+	case ActBot:
+		return dg.setBot(a.Player, a.Bot)
+	case ActUndo:
+		return dg.undo()
+	case ActRedo:
+		return dg.redo()
+	case ActSnapshot:
+		if a.Game == nil {
+			return fmt.Errorf("snapshot action has no game")
+		}
+		g := a.Game.snapshot()
+		dg.restore(&g)
+	default:
+		return fmt.Errorf("can't apply %s action", a.Kind)
+	}
+	return nil
+}
+
+// Replay - rebuild a game from its log
+func Replay(actions []Action) (*DiceGame, error) {
+	return replay(actions, nil)
+}
+
+// Rescore - replay a game's log under a different rules profile. A roll the
+// new rules don't allow is an error. Turns from before a snapshot the log
+// starts from stay as they were scored.
+func Rescore(actions []Action, rules diceturn.Rules) (*DiceGame, error) {
+	return replay(actions, &rules)
+}
+
+func replay(actions []Action, rules *diceturn.Rules) (*DiceGame, error) {
+	if len(actions) == 0 || actions[0].Kind != ActNewGame {
+		return nil, fmt.Errorf("log must start with a %s action", ActNewGame)
+	}
+	first := actions[0]
+	dg, err := NewGame(first.GameID, first.Players...)
+	if err != nil {
+		return nil, fmt.Errorf("replaying %v: %v", first, err)
+	}
+	dg.Log = []Action{first}
+	if rules != nil {
+		if err := dg.setRules(*rules); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, a := range actions[1:] {
+		if rules != nil && a.Kind == ActRules {
+			continue
+		}
+		logged := dg.Log
+		if err := dg.apply(a); err != nil {
+			return nil, fmt.Errorf("replaying %v: %v", a, err)
+		}
+		dg.Log = append(logged, a)
+		if rules != nil && a.Kind == ActSnapshot {
+			dg.Rules = *rules
+		}
+	}
+
+	// Pick the dice source back up where the log left it
+	dg.source = nil
+	return dg, nil
+}
@@ -0,0 +1,109 @@
+package dicegame
+
+import (
+	"encoding/json"
+	"testing"
+
+	"wojones.com/src/diceturn"
+)
+
+func TestReplay(t *testing.T) {
+	dg := newGame(t, "G1", "Alpha", "Beta", "Gamma")
+	rules := diceturn.DefaultRules()
+	rules.ChevronSize = 3
+	rules.ColorDie = 2
+	steps := []struct {
+		what string
+		do   func() error
+	}{
+		{"rules", func() error { return dg.SetRules(rules) }},
+		{"source", func() error { dg.SetDiceSource(NewSeededSource(11)); return nil }},
+		{"Alpha's consecutives", func() error { return dg.RollWith(3, 1, 2) }},
+		{"pass to Beta", func() error { _, e := dg.PassDice("Beta"); return e }},
+		{"Beta's consecutives fill Gamma", func() error { return dg.RollWith(4, 5, 6) }},
+		{"new round", dg.NewRound},
+		{"Gamma pays", func() error { return dg.PayChevron("Gamma", 0) }},
+		{"Gamma rolls", func() error { return dg.RollDice(diceturn.AllDice) }},
+		{"Gamma's die 2 leaves the table", func() error { return dg.RollDiceOff(diceturn.Die2, diceturn.Die2) }},
+		{"pass to Alpha", func() error { _, e := dg.PassDice("Alpha"); return e }},
+	}
+	for _, s := range steps {
+		if e := s.do(); e != nil {
+			t.Fatalf("%s: %v", s.what, e)
+		}
+	}
+	// A refused roll isn't logged, and doesn't use up any dice
+	draws := dg.Draws
+	if e := dg.RollDice(0); e == nil || dg.Draws != draws {
+		t.Errorf("Bad roll allowed (%v) or drew dice (%d, expected %d)", e, dg.Draws, draws)
+	}
+	for i, tp := range dg.Turns {
+		if tp.ColorDie != 2 {
+			t.Errorf("Turn %d has die %d colored, expected 2", i, tp.ColorDie)
+		}
+	}
+	// NewGame logs the game and its dice source
+	if n := len(dg.Log); n != len(steps)+2 {
+		t.Errorf("Logged %d actions, expected %d", n, len(steps)+2)
+	}
+
+	back, err := Replay(dg.Log)
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	want, _ := json.Marshal(dg)
+	got, _ := json.Marshal(back)
+	if string(got) != string(want) {
+		t.Errorf("Replayed game differs:\n%s\nexpected:\n%s", got, want)
+	}
+
+	// Both carry on with the same dice
+	for _, g := range []*DiceGame{dg, back} {
+		if e := g.RollDice(diceturn.AllDice); e != nil {
+			t.Fatalf("Roll after replay: %v", e)
+		}
+	}
+	if a, b := dg.CurrentTurn().Rolls[0], back.CurrentTurn().Rolls[0]; a.RollResults != b.RollResults {
+		t.Errorf("Replayed game rolled %v, original %v", b.RollResults, a.RollResults)
+	}
+
+	if _, err := Replay(dg.Log[1:]); err == nil {
+		t.Errorf("Replayed a log that doesn't start a game")
+	}
+}
+
+func TestRescore(t *testing.T) {
+	dg := newGame(t, "G1", "Alpha", "Beta", "Gamma")
+	if e := dg.RollWith(3, 1, 2); e != nil {
+		t.Fatalf("Roll failed: %v", e)
+	}
+	if _, e := dg.PassDice("Beta"); e != nil {
+		t.Fatalf("Pass failed: %v", e)
+	}
+	// Kept two 2s, so Beta may roll the single die again
+	for _, r := range [][3]int{{2, 2, 5}, {0, 0, 3}, {0, 0, 4}} {
+		if e := dg.RollWith(r[0], r[1], r[2]); e != nil {
+			t.Fatalf("Roll %v failed: %v", r, e)
+		}
+	}
+
+	rules := diceturn.DefaultRules()
+	rules.ConsecMarks = 4
+	g, err := Rescore(dg.Log, rules)
+	if err != nil {
+		t.Fatalf("Rescore: %v", err)
+	}
+	for player, want := range map[string]int32{"Alpha": 0, "Beta": 4, "Gamma": 4} {
+		if c := g.Scores[player].Chevrons[0].Count; c != want {
+			t.Errorf("%s has %d marks after rescoring, expected %d", player, c, want)
+		}
+	}
+	if c := dg.Scores["Beta"].Chevrons[0].Count; c != 2 {
+		t.Errorf("Rescoring changed the original game: Beta has %d marks", c)
+	}
+
+	rules.RerollSingleDie = false
+	if _, err := Rescore(dg.Log, rules); err == nil {
+		t.Errorf("Rescore allowed a roll the rules don't")
+	}
+}
@@ -0,0 +1,244 @@
+package dicegame
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"golang.org/x/exp/slices"
+	"wojones.com/src/diceodds"
+	"wojones.com/src/diceturn"
+	"wojones.com/src/simulate"
+)
+
+// Strategy - how the computer plays a seat. ToRoll picks the dice to roll
+// next, given the turn so far and the turn to beat (nil against the opening),
+// or 0 to stand; PassTo picks who gets the dice, out of targets.
+type Strategy interface {
+	Name() string
+	ToRoll(rules diceturn.Rules, dt diceturn.DiceTurn, prev *diceturn.DiceTurn) int
+	PassTo(dg DiceGame, targets []string) string
+}
+
+// Built-in strategies
+const (
+	BotGreedyLow    = "greedy-low"
+	BotTripleChaser = "triple-chaser"
+	BotOddsOptimal  = "odds-optimal"
+)
+
+var strategies = map[string]Strategy{
+	BotGreedyLow:    greedyLow{},
+	BotTripleChaser: tripleChaser{},
+	BotOddsOptimal:  oddsOptimal{},
+}
+
+// StrategyNamed - a built-in strategy by name
+func StrategyNamed(name string) (Strategy, error) {
+	if st, ok := strategies[name]; ok {
+		return st, nil
+	}
+	return nil, fmt.Errorf("unknown strategy \"%s\" (try %v)", name, StrategyNames())
+}
+
+// StrategyNames - the built-in strategies, sorted
+func StrategyNames() []string {
+	names := make([]string, 0, len(strategies))
+	for name := range strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// beats - does the turn's last roll beat prev (or the opening)?
+func beats(rules diceturn.Rules, dt diceturn.DiceTurn, prev *diceturn.DiceTurn) bool {
+	if dt.NumRolls == 0 {
+		return false
+	}
+	score, special := diceodds.Target(rules, prev)
+	return diceturn.Rank(dt.Rolls[dt.NumRolls-1].TurnValueWith(rules)) < diceturn.Rank(score, special)
+}
+
+// passToWorst - pass to whoever's closest to filling their chevron; if they
+// can't beat the turn, that's where the marks go
+func passToWorst(dg DiceGame, targets []string) string {
+	worst, most := "", -1
+	for _, player := range targets {
+		ps := dg.Scores[player]
+		if n := len(ps.Chevrons); n > 0 && int(ps.Chevrons[n-1].Count) > most {
+			worst, most = player, int(ps.Chevrons[n-1].Count)
+		}
+	}
+	return worst
+}
+
+// greedyLow - keep ones and zeros, and stand as soon as the turn is beaten
+type greedyLow struct{}
+
+func (greedyLow) Name() string { return BotGreedyLow }
+
+func (greedyLow) ToRoll(rules diceturn.Rules, dt diceturn.DiceTurn, prev *diceturn.DiceTurn) int {
+	if beats(rules, dt, prev) {
+		return 0
+	}
+	return simulate.Keep{Max: 1}.ToRoll(rules, dt)
+}
+
+func (greedyLow) PassTo(dg DiceGame, targets []string) string {
+	return passToWorst(dg, targets)
+}
+
+// tripleChaser - holds on to a pair and rolls for the third, whatever the
+// turn to beat; without a pair, plays like greedyLow
+type tripleChaser struct{}
+
+func (tripleChaser) Name() string { return BotTripleChaser }
+
+func (tripleChaser) ToRoll(rules diceturn.Rules, dt diceturn.DiceTurn, prev *diceturn.DiceTurn) int {
+	if dt.NumRolls == 0 {
+		return diceturn.AllDice
+	}
+	last := dt.Rolls[dt.NumRolls-1]
+	if _, special := last.TurnValueWith(rules); special != diceturn.NothingSpecial {
+		return 0
+	}
+	r := last.RollResults
+	for d := 0; d < 3; d++ {
+		// The odd die out, if the other two match
+		if r[(d+1)%3] == r[(d+2)%3] {
+			if toroll := diceturn.Die0 << d; dt.RollCheckWith(rules, toroll) == nil {
+				return toroll
+			}
+		}
+	}
+	return greedyLow{}.ToRoll(rules, dt, prev)
+}
+
+func (tripleChaser) PassTo(dg DiceGame, targets []string) string {
+	return passToWorst(dg, targets)
+}
+
+// oddsOptimal - makes whichever move is most likely to beat the turn, as
+// worked out exactly by diceodds
+type oddsOptimal struct{}
+
+func (oddsOptimal) Name() string { return BotOddsOptimal }
+
+func (oddsOptimal) ToRoll(rules diceturn.Rules, dt diceturn.DiceTurn, prev *diceturn.DiceTurn) int {
+	if dt.NumRolls == 0 {
+		// No choice, and the odds take a while to work out
+		return diceturn.AllDice
+	}
+	cs, err := diceodds.Odds(rules, dt, prev)
+	if err != nil {
+		return 0
+	}
+	return cs[0].ToRoll
+}
+
+func (oddsOptimal) PassTo(dg DiceGame, targets []string) string {
+	return passToWorst(dg, targets)
+}
+
+// SetBot - have the computer play a player's seat, by the named strategy, or
+// give the seat back to a person if the name is empty. If it's the bot's
+// turn, it plays straight away; if it can't, that's a BotError.
+func (dg *DiceGame) SetBot(player string, strategy string) error {
+	dg.mu.Lock()
+	defer dg.mu.Unlock()
+	if err := dg.setBot(player, strategy); err != nil {
+		return err
+	}
+	return dg.saved(dg.playBots())
+}
+
+func (dg *DiceGame) setBot(player string, strategy string) error {
+	if !slices.Contains(dg.Players, player) {
+		return fmt.Errorf("no player %s", player)
+	}
+	if strategy == "" {
+		delete(dg.Bots, player)
+	} else {
+		if _, err := StrategyNamed(strategy); err != nil {
+			return err
+		}
+		if dg.Bots == nil {
+			dg.Bots = map[string]string{}
+		}
+		dg.Bots[player] = strategy
+	}
+	dg.record(Action{Kind: ActBot, Player: player, Bot: strategy})
+	return nil
+}
+
+// IsBot - is the computer playing player's seat?
+func (dg DiceGame) IsBot(player string) bool {
+	_, ok := dg.Bots[player]
+	return ok
+}
+
+// playBots - while a bot has the dice, play its turn: roll from the game's
+// dice source until it stands, then pass. Stops when a person has the dice
+// or the round is over. Everything a bot does is logged like anyone else's
+// rolls and passes, so replaying the log doesn't play the bots again. A bot
+// that can't play stops there, with the dice, and it's a BotError.
+func (dg *DiceGame) playBots() error {
+	for !dg.Over {
+		name, ok := dg.Bots[dg.CurPlayer()]
+		if !ok {
+			return nil
+		}
+		bot, err := StrategyNamed(name)
+		if err == nil {
+			err = dg.playBot(bot)
+		}
+		if err != nil {
+			return &BotError{Player: dg.CurPlayer(), Strategy: name, Err: err}
+		}
+	}
+	return nil
+}
+
+// BotError - a bot couldn't play its turn. Whatever set it off went through;
+// the bot still has the dice.
+type BotError struct {
+	Player   string
+	Strategy string
+	Err      error
+}
+
+func (e *BotError) Error() string {
+	return fmt.Sprintf("bot %s (%s): %v", e.Player, e.Strategy, e.Err)
+}
+
+func (e *BotError) Unwrap() error {
+	return e.Err
+}
+
+// IsBotError - is err a BotError?
+func IsBotError(err error) bool {
+	var be *BotError
+	return errors.As(err, &be)
+}
+
+// playBot - one turn for the bot with the dice
+func (dg *DiceGame) playBot(bot Strategy) error {
+	for dt := dg.CurrentTurn(); dt.NumRolls < 3; dt = dg.CurrentTurn() {
+		toroll := bot.ToRoll(dg.Rules, dt, dg.PrevTurn())
+		if toroll == 0 {
+			break
+		}
+		if err := dg.rollDiceOff(toroll, 0); err != nil {
+			return err
+		}
+		if dg.Over {
+			// Consecutives filled somebody's chevron
+			return nil
+		}
+	}
+	s := dg.snapshot()
+	to := bot.PassTo(s, s.PassTargets())
+	_, err := dg.passDice(to)
+	return err
+}
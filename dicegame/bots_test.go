@@ -0,0 +1,140 @@
+package dicegame
+
+import (
+	"encoding/json"
+	"testing"
+
+	"wojones.com/src/diceturn"
+)
+
+// sameGame - fail unless the two games come out the same as JSON
+func sameGame(t *testing.T, got *DiceGame, want *DiceGame) {
+	t.Helper()
+	g, _ := json.Marshal(got)
+	w, _ := json.Marshal(want)
+	if string(g) != string(w) {
+		t.Errorf("Games differ:\n%s\nexpected:\n%s", g, w)
+	}
+}
+
+func TestBotsPlay(t *testing.T) {
+	dg := newGame(t, "G1", "Alpha", "Beta", "Gamma")
+	dg.SetDiceSource(NewSeededSource(3))
+	if e := dg.SetBot("Beta", BotGreedyLow); e != nil {
+		t.Fatalf("SetBot: %v", e)
+	}
+	if e := dg.SetBot("Gamma", BotOddsOptimal); e != nil {
+		t.Fatalf("SetBot: %v", e)
+	}
+	if e := dg.SetBot("Delta", BotGreedyLow); e == nil {
+		t.Errorf("Set a bot for somebody not playing")
+	}
+	if e := dg.SetBot("Beta", "cheater"); e == nil {
+		t.Errorf("Set a bot with no strategy")
+	}
+
+	if e := dg.RollWith(2, 4, 5); e != nil {
+		t.Fatalf("Roll failed: %v", e)
+	}
+	turns := len(dg.Turns)
+	if _, e := dg.PassDice("Beta"); e != nil {
+		t.Fatalf("Pass failed: %v", e)
+	}
+	if !dg.Over && dg.CurPlayer() != "Alpha" {
+		t.Fatalf("Bots stopped with %s rolling", dg.CurPlayer())
+	}
+	if len(dg.Turns) < turns+2 {
+		t.Fatalf("Bots didn't play: %d turns", len(dg.Turns))
+	}
+	for _, tp := range dg.Turns[turns : len(dg.Turns)-1] {
+		if tp.NumRolls == 0 || tp.Settled == nil {
+			t.Errorf("Bot turn not played out: %v", tp)
+		}
+	}
+
+	back, err := Replay(dg.Log)
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	sameGame(t, back, dg)
+
+	// Undoing takes back everything the bots did after Alpha passed
+	if dg.Over {
+		t.Fatalf("Round over before Alpha got the dice back: %s", dg.GameStatus())
+	}
+	played := dg.Snapshot()
+	if e := dg.Undo(); e != nil {
+		t.Fatalf("Undo: %v", e)
+	}
+	if len(dg.Turns) != turns || dg.CurPlayer() != "Alpha" {
+		t.Errorf("Undo left %d turns with %s rolling, expected %d with Alpha", len(dg.Turns), dg.CurPlayer(), turns)
+	}
+	if e := dg.Redo(); e != nil {
+		t.Fatalf("Redo: %v", e)
+	}
+	if len(dg.Turns) != len(played.Turns) || dg.CurPlayer() != "Alpha" {
+		t.Errorf("Redo left %d turns with %s rolling, expected %d with Alpha", len(dg.Turns), dg.CurPlayer(), len(played.Turns))
+	}
+}
+
+func TestBotsOnly(t *testing.T) {
+	dg := newGame(t, "G1", "Alpha", "Beta", "Gamma")
+	rules := diceturn.DefaultRules()
+	rules.ChevronSize = 10
+	rules.PassMode = diceturn.PassRotation
+	if e := dg.SetRules(rules); e != nil {
+		t.Fatalf("SetRules: %v", e)
+	}
+	dg.SetDiceSource(NewSeededSource(5))
+	for _, strategy := range StrategyNames() {
+		if _, err := StrategyNamed(strategy); err != nil {
+			t.Fatalf("StrategyNamed(%s): %v", strategy, err)
+		}
+	}
+	// Once the last seat goes to the computer, the bots play out the round
+	for i, player := range dg.Players {
+		if e := dg.SetBot(player, StrategyNames()[i]); e != nil {
+			t.Fatalf("SetBot %s: %v", player, e)
+		}
+	}
+	if !dg.Over {
+		t.Fatalf("Bots stopped with %s rolling: %s", dg.CurPlayer(), dg.GameStatus())
+	}
+	back, err := Replay(dg.Log)
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	sameGame(t, back, dg)
+}
+
+// passesToNobody - a strategy that rolls like greedy-low, then can't pass
+type passesToNobody struct {
+	greedyLow
+}
+
+func (passesToNobody) Name() string                                { return "to-nobody" }
+func (passesToNobody) PassTo(dg DiceGame, targets []string) string { return "Nobody" }
+
+func TestBotError(t *testing.T) {
+	strategies["to-nobody"] = passesToNobody{}
+	defer delete(strategies, "to-nobody")
+
+	dg := newGame(t, "G1", "Alpha", "Beta", "Gamma")
+	dg.SetDiceSource(NewSeededSource(1))
+	if err := dg.SetBot("Beta", "to-nobody"); err != nil {
+		t.Fatalf("SetBot: %v", err)
+	}
+	if e := dg.RollDice(diceturn.AllDice); e != nil {
+		t.Fatalf("Roll failed: %v", e)
+	}
+	if _, err := dg.PassDice("Beta"); !IsBotError(err) {
+		t.Fatalf("Bot that can't pass: %v", err)
+	}
+	// The pass went through; the bot rolled, and still has the dice
+	if s := dg.Snapshot(); s.CurPlayer() != "Beta" || s.CurrentTurn().NumRolls == 0 {
+		t.Errorf("Expected Beta to have rolled and kept the dice: %s", s.CurTurn())
+	}
+	if err := dg.Redo(); err == nil || IsBotError(err) {
+		t.Errorf("Redo with nothing to redo: %v", err)
+	}
+}
@@ -1,35 +1,228 @@
 package dicegame
 
 import (
+	"errors"
 	"fmt"
 	"strings"
+	"sync"
 
 	"golang.org/x/exp/slices"
+	"wojones.com/src/diceodds"
 	"wojones.com/src/dicescore"
 	"wojones.com/src/diceturn"
 )
 
+// DiceGame - a game of 3 dice. Its methods can be called from any number of
+// goroutines; one thing at a time is done to the game. To look at the game
+// while it's being played, take a Snapshot.
+//
+// Start a game with NewGame, or get one from Replay, Rescore or decoding its
+// JSON. Those set up the lock; a DiceGame made any other way, like a plain
+// DiceGame{}, has none, and its methods that lock the game panic.
 type DiceGame struct {
-	ID         string                           `json:"game_id"`
-	Players    []string                         `json:"players"`
-	Scores     map[string]dicescore.PlayerScore `json:"scores"`
-	PrevPlayer *string                          `json:"prev_player"`
-	PrevTurn   *diceturn.DiceTurn
-	CurPlayer  *string             `json:"cur_player"`
+	Version int                              `json:"version"`
+	ID      string                           `json:"game_id"`
+	Players []string                         `json:"players"`
+	Scores  map[string]dicescore.PlayerScore `json:"scores"`
+	// The seats the computer plays, and the strategy each one plays by
+	Bots map[string]string `json:"bots,omitempty"`
+	// Who's rolling and who rolled last, as indexes in Players, and the turn
+	// to beat, as an index in Turns. -1 for nobody and no turn, at the start
+	// of a round.
+	Cur        int `json:"cur"`
+	Prev       int `json:"prev"`
+	PrevTurnNo int `json:"prev_turn"`
+	// Which way round the table the dice are going, under the rotation pass
+	// rule: 1 in the order of Players, -1 the other way, 0 until the first
+	// pass of the round
+	Direction  int                 `json:"direction,omitempty"`
 	Turns      []diceturn.DiceTurn `json:"turns"`
+	Rules      diceturn.Rules      `json:"rules"`
+	SourceKind string              `json:"dice_source"`
+	Seed       int64               `json:"dice_seed,omitempty"`
+	Draws      int                 `json:"dice_draws"`
+	Round      int                 `json:"round"`
+	Over       bool                `json:"is_over"`
+	Loser      string              `json:"loser,omitempty"`
+	Log        []Action            `json:"log"`
+	source     DiceSource
+	bus        *EventBus
+	store      Store
+	saveErr    error
+	quiet      bool
+	mu         *sync.Mutex // set up by NewGame and UnmarshalJSON
 }
 
-func NewGame(ID string, p1 string, p2 string, p3 string) DiceGame {
-	dg := DiceGame{ID: ID, Players: []string{p1, p2, p3},
-		Scores: map[string]dicescore.PlayerScore{p1: {Player: p1},
-			p2: {Player: p2, Chevrons: []dicescore.Chevron{{}, {}}},
-			p3: {Player: p3}}}
-	dg.CurPlayer = &dg.Players[0]
+// GameOverError - somebody filled a chevron; nothing more happens in the round
+// until a new one is started
+type GameOverError struct {
+	Round int
+	Loser string
+}
+
+func (e *GameOverError) Error() string {
+	return fmt.Sprintf("round %d is over: %s filled a chevron and pays", e.Round, e.Loser)
+}
+
+// IsGameOver - is err a GameOverError?
+func IsGameOver(err error) bool {
+	var ge *GameOverError
+	return errors.As(err, &ge)
+}
+
+// Players in a game: you can't play alone, and more than this and the dice
+// take forever to get round the table
+const (
+	MinPlayers = 2
+	MaxPlayers = 8
+)
+
+// checkName - is name good for a new player, alongside players?
+func checkName(name string, players []string) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("player needs a name")
+	}
+	if slices.Contains(players, name) {
+		return fmt.Errorf("already a player %s", name)
+	}
+	return nil
+}
+
+// NewGame - a game between the players, who roll in the order given
+func NewGame(ID string, players ...string) (*DiceGame, error) {
+	if len(players) < MinPlayers || len(players) > MaxPlayers {
+		return nil, fmt.Errorf("need %d to %d players, not %d", MinPlayers, MaxPlayers, len(players))
+	}
+	for i, player := range players {
+		if err := checkName(player, players[:i]); err != nil {
+			return nil, err
+		}
+	}
+	dg := &DiceGame{Version: SchemaVersion, ID: ID, Players: slices.Clone(players),
+		Scores: map[string]dicescore.PlayerScore{}, Cur: 0, Prev: -1, PrevTurnNo: -1,
+		Round: 1, bus: NewEventBus(), mu: &sync.Mutex{}}
 	dg.Turns = []diceturn.DiceTurn{{Player: dg.Players[0], Score: 0, NumRolls: 0}}
 	for _, player := range dg.Players {
-		dg.Scores[player] = dicescore.PlayerScore{Player: player, Chevrons: []dicescore.Chevron{{Count: 0, Filled: false, Paid: false}}}
+		dg.Scores[player] = dicescore.NewPlayerScore(player)
+	}
+	dg.record(Action{Kind: ActNewGame, GameID: ID, Players: slices.Clone(players)})
+	dg.setDiceSource(NewCryptoSource())
+	dg.Rules = diceturn.DefaultRules()
+	return dg, nil
+}
+
+// Snapshot - a copy of the game as it stands, sharing nothing with it, to
+// look at while the game carries on
+func (dg *DiceGame) Snapshot() DiceGame {
+	dg.mu.Lock()
+	defer dg.mu.Unlock()
+	return dg.snapshot()
+}
+
+func (dg *DiceGame) snapshot() DiceGame {
+	s := *dg
+	s.Players = slices.Clone(dg.Players)
+	s.Scores = make(map[string]dicescore.PlayerScore, len(dg.Scores))
+	for player, ps := range dg.Scores {
+		ps.Chevrons = slices.Clone(ps.Chevrons)
+		s.Scores[player] = ps
+	}
+	if dg.Bots != nil {
+		s.Bots = make(map[string]string, len(dg.Bots))
+		for player, name := range dg.Bots {
+			s.Bots[player] = name
+		}
+	}
+	s.Turns = slices.Clone(dg.Turns)
+	for i := range s.Turns {
+		s.Turns[i].Rolls = slices.Clone(s.Turns[i].Rolls)
+	}
+	s.Log = slices.Clone(dg.Log)
+	s.source, s.bus, s.store = nil, nil, nil
+	s.mu = &sync.Mutex{}
+	return s
+}
+
+// newTurn - a turn for the player with the dice, using the game's colored die
+func (dg *DiceGame) newTurn() diceturn.DiceTurn {
+	dt := diceturn.NewTurn(dg.CurPlayer())
+	dt.ColorDie = dg.Rules.ColorDie
+	return dt
+}
+
+// CurPlayer - who's rolling
+func (dg DiceGame) CurPlayer() string {
+	return dg.Players[dg.Cur]
+}
+
+// PrevPlayer - who rolled last; "" at the start of a round
+func (dg DiceGame) PrevPlayer() string {
+	if dg.Prev < 0 {
+		return ""
+	}
+	return dg.Players[dg.Prev]
+}
+
+// PrevTurn - the turn to beat; nil at the start of a round. Good until the
+// game changes.
+func (dg DiceGame) PrevTurn() *diceturn.DiceTurn {
+	if dg.PrevTurnNo < 0 {
+		return nil
+	}
+	return &dg.Turns[dg.PrevTurnNo]
+}
+
+// SetRules - play by the given house rules. Only before anyone has rolled;
+// changing the rules mid-game is how fights start.
+func (dg *DiceGame) SetRules(rules diceturn.Rules) error {
+	dg.mu.Lock()
+	defer dg.mu.Unlock()
+	return dg.saved(dg.setRules(rules))
+}
+
+func (dg *DiceGame) setRules(rules diceturn.Rules) error {
+	if err := rules.Validate(); err != nil {
+		return err
+	}
+	if len(dg.Turns) > 1 || dg.Turns[0].NumRolls > 0 {
+		return fmt.Errorf("cannot change rules once the game has started")
+	}
+	dg.Rules = rules
+	dg.Turns[0].ColorDie = rules.ColorDie
+	dg.record(Action{Kind: ActRules, Rules: &rules})
+	return nil
+}
+
+// SetDiceSource - use the given source for RollDice, and record what it is so
+// a seeded game can be reproduced
+func (dg *DiceGame) SetDiceSource(src DiceSource) error {
+	dg.mu.Lock()
+	defer dg.mu.Unlock()
+	dg.setDiceSource(src)
+	return dg.saved(nil)
+}
+
+func (dg *DiceGame) setDiceSource(src DiceSource) {
+	dg.source = src
+	dg.SourceKind = src.Kind()
+	dg.Seed = src.Seed()
+	dg.Draws = 0
+	dg.record(Action{Kind: ActSource, Source: dg.SourceKind, Seed: dg.Seed})
+}
+
+// SetQuiet - stop (or start again) printing what the game's doing as it
+// goes; for games nobody's watching, like a tournament's
+func (dg *DiceGame) SetQuiet(quiet bool) {
+	dg.mu.Lock()
+	defer dg.mu.Unlock()
+	dg.quiet = quiet
+}
+
+// debugf - print what the game's doing, unless it's been told to be quiet
+func (dg *DiceGame) debugf(format string, args ...interface{}) {
+	if !dg.quiet {
+		fmt.Printf(format, args...)
 	}
-	return dg
 }
 
 // | || ||| |||| +++++ +++++
@@ -37,10 +230,10 @@ func centerin(s string, width int) string {
 	return fmt.Sprintf("%[1]*s", -width, fmt.Sprintf("%[1]*s", (width+len(s))/2, s))
 }
 
-func asticks(count int) string {
+func asticks(count int, size int) string {
 	s := ""
 	const twidth = 5
-	for i := 0; i < 4; i++ {
+	for i := 0; i < (size+twidth-1)/twidth; i++ {
 		if count <= 0 {
 			s += strings.Repeat(" ", twidth)
 		} else if count > 5 {
@@ -53,13 +246,41 @@ func asticks(count int) string {
 	return s
 }
 
+// chevronticks - a chevron as tick marks, flagged $ if paid or * if filled
+func chevronticks(c dicescore.Chevron, size int) string {
+	s := strings.TrimRight(asticks(int(c.Count), size), " ")
+	if c.Paid {
+		s = "$" + s
+	} else if c.Filled {
+		s = "*" + s
+	}
+	return s
+}
+
 func (dg DiceGame) String() string {
-	headline := fmt.Sprintf("Game %s: %s", dg.ID, strings.Join(dg.Players, ", "))
+	seats := make([]string, len(dg.Players))
+	for i, player := range dg.Players {
+		seats[i] = player
+		if strategy, ok := dg.Bots[player]; ok {
+			seats[i] += " (" + strategy + ")"
+		}
+	}
+	headline := fmt.Sprintf("Game %s: %s", dg.ID, strings.Join(seats, ", "))
 	return headline
 }
 
 func (dg DiceGame) Scorecard() string {
-	const pwidth = 20
+	// Room for the ticks of a full chevron, plus its paid/filled flag, and for
+	// everybody's name
+	pwidth := 20
+	if w := len(asticks(dg.Rules.ChevronSize, dg.Rules.ChevronSize)) + 2; w > pwidth {
+		pwidth = w
+	}
+	for _, player := range dg.Players {
+		if w := len(player) + 2; w > pwidth {
+			pwidth = w
+		}
+	}
 	scorecard := ""
 
 	for idx, player := range dg.Players {
@@ -71,20 +292,83 @@ func (dg DiceGame) Scorecard() string {
 	}
 
 	scorecard += "\n" + strings.Repeat(strings.Repeat("-", pwidth), len(dg.Players)) + "\n"
-	for idx, player := range dg.Players {
-		if idx > 0 {
-			scorecard += "|"
+
+	nchevrons := 0
+	for _, player := range dg.Players {
+		if n := len(dg.Scores[player].Chevrons); n > nchevrons {
+			nchevrons = n
 		}
-		if len(dg.Scores[player].Chevrons) > 0 {
-			scorecard += centerin(asticks(int(dg.Scores[player].Chevrons[0].Count)), pwidth)
-			// score := fmt.Sprintf("%d", dg.Scores[player].Chevrons[0].Count)
-			// scorecard += centerin(score, pwidth)
-		} else {
-			scorecard += strings.Repeat(" ", pwidth)
+	}
+	for cidx := 0; cidx < nchevrons; cidx++ {
+		for idx, player := range dg.Players {
+			if idx > 0 {
+				scorecard += "|"
+			}
+			if chevrons := dg.Scores[player].Chevrons; cidx < len(chevrons) {
+				scorecard += centerin(chevronticks(chevrons[cidx], dg.Rules.ChevronSize), pwidth)
+			} else {
+				scorecard += strings.Repeat(" ", pwidth)
+			}
 		}
+		scorecard += "\n"
+	}
+	if tp := dg.CurrentTurn(); tp.RolledBy != "" && !dg.Over {
+		scorecard += fmt.Sprintf("(%s is rolling for %s)\n", tp.RolledBy, tp.Player)
+	}
+
+	return scorecard
+}
+
+// overErr - a GameOverError if the round is over
+func (dg *DiceGame) overErr() error {
+	if dg.Over {
+		return &GameOverError{Round: dg.Round, Loser: dg.Loser}
+	}
+	return nil
+}
+
+// NewRound - start a new round after one is over. The loser opens a new
+// chevron and starts the round, against the opening value.
+func (dg *DiceGame) NewRound() error {
+	dg.mu.Lock()
+	defer dg.mu.Unlock()
+	if err := dg.newRound(); err != nil {
+		return err
+	}
+	return dg.saved(dg.playBots())
+}
+
+func (dg *DiceGame) newRound() error {
+	if !dg.Over {
+		return fmt.Errorf("round %d isn't over yet", dg.Round)
 	}
+	ps := dg.Scores[dg.Loser]
+	if err := ps.OpenChevron(); err != nil {
+		return err
+	}
+	dg.Scores[dg.Loser] = ps
+
+	idx := slices.Index(dg.Players, dg.Loser)
+	dg.Round++
+	dg.Over = false
+	dg.Loser = ""
+	dg.Prev = -1
+	dg.PrevTurnNo = -1
+	dg.Direction = 0
+	dg.Cur = idx
+	dg.Turns = append(dg.Turns, dg.newTurn())
+	dg.record(Action{Kind: ActNewRound})
+	dg.publish(Event{Kind: EventNewRound, Player: dg.CurPlayer(),
+		Text: fmt.Sprintf("Round %d: %s starts", dg.Round, dg.CurPlayer())})
+	return nil
+}
 
-	return scorecard + "\n"
+// GameStatus - one line on how the round stands
+func (dg DiceGame) GameStatus() string {
+	if dg.Over {
+		return fmt.Sprintf("Round %d is OVER: %s filled a chevron and pays!", dg.Round, dg.Loser)
+	}
+	return fmt.Sprintf("Round %d: %s is rolling", dg.Round, dg.CurrentTurn().Who())
 }
 
 func (dg DiceGame) CurrentTurn() diceturn.DiceTurn {
@@ -93,149 +377,324 @@ func (dg DiceGame) CurrentTurn() diceturn.DiceTurn {
 func (dg DiceGame) CurTurn() string {
 	tp := dg.Turns[len(dg.Turns)-1]
 	s := fmt.Sprintf("%v", tp)
-	if dg.PrevTurn != nil {
-		s += fmt.Sprintf("\n\tAgainst %s's %s", dg.PrevTurn.Player,
-			dg.PrevTurn.RollString())
+	if pt := dg.PrevTurn(); pt != nil {
+		s += fmt.Sprintf("\n\tAgainst %s's %s", pt.Who(), pt.RollString())
 	} else {
-		s += "\n\tto start the game!!!"
+		s += fmt.Sprintf("\n\tto start the game!!! (beat %d)", dg.Rules.OpeningValue)
 	}
 
 	return s
 }
 
+// Odds - the moves open to whoever has the dice, best first, and their
+// chances against the turn they have to beat
+func (dg DiceGame) Odds() ([]diceodds.Choice, error) {
+	if err := dg.overErr(); err != nil {
+		return nil, err
+	}
+	return diceodds.Odds(dg.Rules, dg.CurrentTurn(), dg.PrevTurn())
+}
+
 func (dg *DiceGame) RollCheck(dmap int) error {
-	return dg.Turns[len(dg.Turns)-1].RollCheck(dmap)
+	dg.mu.Lock()
+	defer dg.mu.Unlock()
+	return dg.rollCheck(dmap)
+}
+
+func (dg *DiceGame) rollCheck(dmap int) error {
+	if err := dg.overErr(); err != nil {
+		return err
+	}
+	return dg.Turns[len(dg.Turns)-1].RollCheckWith(dg.Rules, dmap)
 }
 
+// RollWith - roll with the given values, 0 for a die not rolled
 func (dg *DiceGame) RollWith(d1 int, d2 int, d3 int) error {
-	fmt.Printf("Rolling %d %d %d\n", d1, d2, d3)
-	tp := &dg.Turns[len(dg.Turns)-1]
-	if tp.NumRolls >= 3 {
-		return fmt.Errorf("cannot roll (%d rolls already)", tp.NumRolls)
+	return dg.RollWithOff(0, d1, d2, d3)
+}
+
+// RollWithOff - roll with the given values, 0 for a die not rolled. The dice in
+// the off bitmap left the table: the roller takes the penalty, and those dice
+// are rerolled from the game's dice source.
+func (dg *DiceGame) RollWithOff(off int, d1 int, d2 int, d3 int) error {
+	dg.mu.Lock()
+	defer dg.mu.Unlock()
+	return dg.saved(dg.logRoll(Action{Kind: ActRoll, Dice: [3]int{d1, d2, d3}, Off: off}, dg.Draws))
+}
+
+// logRoll - make the roll, and log it with the dice drawn since draws
+func (dg *DiceGame) logRoll(a Action, draws int) error {
+	if err := dg.rollWith(&a); err != nil {
+		return err
+	}
+	a.Draws = dg.Draws - draws
+	dg.record(a)
+	return nil
+}
+
+// rollWith - roll the dice in the action. Off-table dice are rerolled from
+// the game's dice source, unless the action already says what they rerolled.
+func (dg *DiceGame) rollWith(a *Action) error {
+	if err := dg.overErr(); err != nil {
+		return err
 	}
-	fmt.Printf("Before: %v\n", tp)
+	off := a.Off
+	dg.debugf("Rolling %d %d %d\n", a.Dice[0], a.Dice[1], a.Dice[2])
+	tp := &dg.Turns[len(dg.Turns)-1]
+	dg.debugf("Before: %v\n", tp)
 
+	// The dice rolled are the ones given a value
 	toroll := 0
-	nroll := 0
-	if d1 > 0 {
-		toroll |= diceturn.Die0
-		nroll++
+	for d, val := range a.Dice {
+		if val < 0 || val > 6 {
+			return fmt.Errorf("invalid value for a die: %d", val)
+		}
+		if val > 0 {
+			toroll |= diceturn.Die0 << d
+		}
 	}
-	if d2 > 0 {
-		toroll |= diceturn.Die1
-		nroll++
+	dg.debugf("Asking to roll: %03b\n", toroll)
+
+	// Check before drawing any dice for the off-table rerolls
+	if err := tp.RollCheckWith(dg.Rules, toroll); err != nil {
+		return err
 	}
-	if d3 > 0 {
-		toroll |= diceturn.Die2
-		nroll++
+	if off&^toroll != 0 {
+		return fmt.Errorf("only rolled dice can leave the table (not 0b%03b)", off&^toroll)
 	}
 
-	if toroll == 0 {
-		return fmt.Errorf("rolling no dice is not a roll")
+	// Forced reroll of anything that left the table
+	dice := a.Dice
+	for d := 0; d < 3; d++ {
+		if off&(diceturn.Die0<<d) != 0 {
+			if a.Rerolls[d] == 0 {
+				v, err := dg.draw()
+				if err != nil {
+					return err
+				}
+				a.Rerolls[d] = v
+			}
+			dice[d] = a.Rerolls[d]
+		}
 	}
-	fmt.Printf("Asking to roll: %03b\n", toroll)
 
-	var prevroll *diceturn.DiceRoll = nil
+	if err := tp.TurnRollWith(dg.Rules, toroll, dice[0], dice[1], dice[2]); err != nil {
+		return err
+	}
+	drp := &tp.Rolls[tp.NumRolls-1]
+	if off != 0 {
+		drp.OffTable = off
+		dg.debugf("Off the table: 0b%03b, rerolled to %v\n", off, drp.RollResults)
+	}
+	dg.debugf("After: %v\n", tp)
 
-	switch tp.NumRolls {
-	case 0:
-		if toroll != diceturn.AllDice {
-			return fmt.Errorf("must roll all dice on the first roll")
-		}
+	if tp.NumRolls > 1 {
+		kept := tp.Rolls[tp.NumRolls-2].Kept
+		dg.publish(Event{Kind: EventKeep, Player: tp.Player, Kept: kept,
+			Text: fmt.Sprintf("%s keeps 0b%03b", tp.Player, kept)})
+	}
+	dr := *drp
+	dg.publish(Event{Kind: EventRoll, Player: tp.Player, Roll: &dr,
+		Text: fmt.Sprintf("%s rolls %v: %s", tp.Who(), dr.RollResults, dr.TurnValueStringWith(dg.Rules))})
 
-	case 1:
-		prevroll = &tp.Rolls[0]
-		prevroll.Kept = ^toroll & diceturn.AllDice
-		fmt.Printf("Kept from roll 1: 0x%03b, rolling 0x%03b, reroll 0x%03b\n",
-			tp.Rolls[0].Kept, toroll, tp.Rolls[0].Kept&toroll)
+	return dg.scoreRoll(tp)
+}
 
-		// FWIW: This cannot happen in this code factoring where the inputs determine which
-		// dice are being rolled, and in turn which were kept on the prior roll (5 lines above)
-		// BUT: Keep the code for when (if) it gets refactored
-		if tp.Rolls[0].Kept&toroll != 0 {
-			return fmt.Errorf("cannot re-roll a kept die (0x%03b)", tp.Rolls[0].Kept&toroll)
-		}
+// scoreRoll - marks that are scored as soon as the dice stop: a penalty for
+// each die that left the table, and consecutives. Recorded in the roll.
+func (dg *DiceGame) scoreRoll(tp *diceturn.DiceTurn) error {
+	drp := &tp.Rolls[tp.NumRolls-1]
 
-	case 2:
-		prevroll = &tp.Rolls[1]
-		prevroll.Kept = ^toroll & diceturn.AllDice
-		allkept := prevroll.Kept | tp.Rolls[0].Kept
+	if n := drp.NumOffTable(); n > 0 && dg.Rules.OffTableMarks > 0 {
+		drp.Marks = append(drp.Marks, diceturn.Marking{Player: tp.Player,
+			Marks: n * dg.Rules.OffTableMarks, Reason: diceturn.MarkOffTable})
+	}
 
-		fmt.Printf("Kept from roll 2: 0x%03b, rolling 0x%03b, reroll 0x%03b\n",
-			tp.Rolls[1].Kept, toroll, tp.Rolls[0].Kept&toroll)
-		if nroll > 1 {
-			// ASSERT: allkept & toroll is nonzero; this is the same case as below, really
+	if drp.Consecs {
+		// Only what this roll added to the turn's consecutives
+		cs, err := tp.ConsecScoreWith(dg.Rules, tp.NumRolls)
+		if err != nil {
+			return err
+		}
+		prev, err := tp.ConsecScoreWith(dg.Rules, tp.NumRolls-1)
+		if err != nil {
+			return err
 		}
-		if allkept&toroll != 0 {
-			// The special case: after roll 2, they can pick up their original dice IFF rolling for fives
-			// FOR NOW: Just error out if rerolling
-			return fmt.Errorf("cannot reroll a kept die")
+		if cs -= prev; cs > 0 {
+			if dg.Rules.ConsecsToOthers {
+				for _, player := range dg.Players {
+					if player != tp.Player {
+						drp.Marks = append(drp.Marks, diceturn.Marking{Player: player,
+							Marks: cs, Reason: diceturn.MarkConsecs})
+					}
+				}
+			} else {
+				drp.Marks = append(drp.Marks, diceturn.Marking{Player: tp.Player,
+					Marks: cs, Reason: diceturn.MarkConsecs})
+			}
 		}
 	}
 
-	// Make sure they're keeping at least one die for roll two
-	// AND: if they kept two dice on roll one, they can re-roll the remaining
-	// die on turn 3
-	// Validate they're not re-rolling a kept die
+	// Once somebody fills a chevron, nobody else takes anything
+	for i, m := range drp.Marks {
+		if dg.Over {
+			drp.Marks = drp.Marks[:i]
+			break
+		}
+		if err := dg.addMarks(m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-	// if tp.NumRolls == 2 {
-	// Make sure nothing kept is being rerolled
-	// TODO: The triple-5 exception (can roll one kept die if two rolled die are both fives)
-	// }
+// PassDice - close out the current turn, settle it against the turn before it
+// and pass the dice to player. Returns the settlement for the closed turn.
+//
+// If the settlement fills a chevron, the round is over and nobody gets the
+// dice until NewRound.
+func (dg *DiceGame) PassDice(player string) (diceturn.Settlement, error) {
+	dg.mu.Lock()
+	defer dg.mu.Unlock()
+	st, err := dg.passDice(player)
+	if err == nil {
+		err = dg.playBots()
+	}
+	return st, dg.saved(err)
+}
 
-	// TODO: Make sure they're only rolling one die
-	// Update prior roll's kept value
-	//fmt.Printf("Set kept for roll %d to 0x%03b\n", tp.NumRolls-1, ^toroll&diceturn.AllDice)
-	//tp.Rolls[tp.NumRolls-1].Kept = ^toroll & diceturn.AllDice
-	// }
+func (dg *DiceGame) passDice(player string) (diceturn.Settlement, error) {
+	if err := dg.overErr(); err != nil {
+		return diceturn.Settlement{}, err
+	}
+	if err := dg.passCheck(player); err != nil {
+		return diceturn.Settlement{}, err
+	}
+	idx := slices.Index(dg.Players, player)
 
-	tp.Rolls = append(tp.Rolls, diceturn.DiceRoll{Rolled: toroll})
-	drp := &tp.Rolls[tp.NumRolls]
+	dg.debugf("PassDice: passing to %s\n", dg.Players[idx])
 
-	if tp.NumRolls != 0 {
-		drp.RollResults = tp.Rolls[tp.NumRolls-1].RollResults
+	st, err := dg.Turns[len(dg.Turns)-1].CloseTurnAgainst(dg.Rules, dg.PrevTurn())
+	if err != nil {
+		return st, err
 	}
-	if toroll&diceturn.Die0 != 0 {
-		drp.RollResults[0] = d1
-	}
-	if toroll&diceturn.Die1 != 0 {
-		drp.RollResults[1] = d2
+	if st.Loser != "" {
+		m := diceturn.Marking{Player: st.Loser, Marks: st.Marks, Reason: diceturn.MarkSettled}
+		if err := dg.addMarks(m); err != nil {
+			return st, err
+		}
 	}
-	if toroll&diceturn.Die2 != 0 {
-		drp.RollResults[2] = d3
+	dg.debugf("PassDice: %v\n", st)
+	if dg.Over {
+		dg.record(Action{Kind: ActPass, Player: player})
+		return st, nil
 	}
 
-	if drp.IsConsec() {
-		drp.Consecs = true
+	if dg.Rules.PassMode == diceturn.PassRotation && dg.Direction == 0 {
+		dg.Direction = dg.passDirection(idx)
 	}
+	dg.Prev = dg.Cur
+	dg.PrevTurnNo = len(dg.Turns) - 1
+	dg.Cur = idx
+	dg.Turns = append(dg.Turns, dg.newTurn())
+	dg.record(Action{Kind: ActPass, Player: player})
+	dg.publish(Event{Kind: EventPass, Player: dg.CurPlayer(), Settlement: &st,
+		Text: fmt.Sprintf("%s passes to %s: %v", st.Player, dg.CurPlayer(), st)})
+	return st, nil
+}
 
-	tp.NumRolls++
-	fmt.Printf("After: %v\n", tp)
+// addMarks - tally marks on a player's scorecard
+func (dg *DiceGame) addMarks(m diceturn.Marking) error {
+	player := m.Player
+	ps, ok := dg.Scores[player]
+	if !ok {
+		return fmt.Errorf("no score for player %s", player)
+	}
+	filled, err := ps.AddMarks(m.Marks, dg.Rules.ChevronSize)
+	if err != nil {
+		return err
+	}
+	if filled {
+		dg.debugf("%s filled chevron %d! Round %d is over.\n", player, len(ps.Chevrons), dg.Round)
+		dg.Over = true
+		dg.Loser = player
+	}
+	dg.Scores[player] = ps
 
-	// TODO: If tp.NumRolls >= 3 then the turn is over!
+	dg.publish(Event{Kind: EventMark, Player: player, Marking: &m, Text: m.String()})
+	if filled {
+		dg.publish(Event{Kind: EventGameOver, Player: player, Text: dg.GameStatus()})
+	}
 	return nil
 }
 
-func (dg *DiceGame) PassDice(player string) int {
-	idx := slices.IndexFunc(dg.Players, func(s string) bool { return s == player })
-	if idx < 0 {
-		fmt.Printf("PassDice: no player %s?\n", player)
-		return -1
-	}
+// PayChevron - player paid up for their filled chevron idx (from 0)
+func (dg *DiceGame) PayChevron(player string, idx int) error {
+	dg.mu.Lock()
+	defer dg.mu.Unlock()
+	return dg.saved(dg.payChevron(player, idx))
+}
 
-	fmt.Printf("PassDice: passing to %s\n", dg.Players[idx])
+func (dg *DiceGame) payChevron(player string, idx int) error {
+	ps, ok := dg.Scores[player]
+	if !ok {
+		return fmt.Errorf("no score for player %s", player)
+	}
+	if err := ps.PayChevron(idx); err != nil {
+		return err
+	}
+	dg.Scores[player] = ps
+	dg.record(Action{Kind: ActPay, Player: player, Chevron: idx})
+	dg.publish(Event{Kind: EventPaid, Player: player,
+		Text: fmt.Sprintf("%s paid for chevron %d", player, idx+1)})
+	return nil
+}
 
-	// TODO: Cleanup the last turn, assign score, etc
-	dg.Turns[len(dg.Turns)-1].CloseTurn()
+// RollDice - roll the dice in the toroll bitmap using the game's dice source,
+// with the same checks as RollWith
+func (dg *DiceGame) RollDice(toroll int) error {
+	return dg.RollDiceOff(toroll, 0)
+}
 
-	dg.PrevPlayer = dg.CurPlayer
-	dg.PrevTurn = &dg.Turns[len(dg.Turns)-1]
-	dg.CurPlayer = &dg.Players[idx]
+// RollDiceOff - as RollDice, but the dice in the off bitmap left the table
+func (dg *DiceGame) RollDiceOff(toroll int, off int) error {
+	dg.mu.Lock()
+	defer dg.mu.Unlock()
+	return dg.saved(dg.rollDiceOff(toroll, off))
+}
 
-	dg.Turns = append(dg.Turns, diceturn.NewTurn(*dg.CurPlayer))
-	return 0
+func (dg *DiceGame) rollDiceOff(toroll int, off int) error {
+	// Check first, so a bad roll doesn't use up dice from the source
+	if err := dg.rollCheck(toroll); err != nil {
+		return err
+	}
+	if off&^toroll != 0 {
+		return fmt.Errorf("only rolled dice can leave the table (not 0b%03b)", off&^toroll)
+	}
+	draws := dg.Draws
+	dice := [3]int{}
+	for d := 0; d < 3; d++ {
+		if toroll&(diceturn.Die0<<d) != 0 {
+			v, err := dg.draw()
+			if err != nil {
+				return err
+			}
+			dice[d] = v
+		}
+	}
+	return dg.logRoll(Action{Kind: ActRoll, Dice: dice, Off: off}, draws)
 }
 
-func (dg *DiceGame) RollDice() int {
-	return 0
+// draw - one die from the game's dice source
+func (dg *DiceGame) draw() (int, error) {
+	if dg.source == nil {
+		// Game was decoded from JSON; pick up the recorded source
+		src, err := sourceFor(dg.SourceKind, dg.Seed, dg.Draws)
+		if err != nil {
+			return 0, err
+		}
+		dg.source = src
+	}
+	dg.Draws++
+	return dg.source.Roll(), nil
 }
@@ -0,0 +1,309 @@
+package dicegame
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"wojones.com/src/diceturn"
+)
+
+// newGame - a game to test with
+func newGame(t *testing.T, ID string, players ...string) *DiceGame {
+	t.Helper()
+	dg, err := NewGame(ID, players...)
+	if err != nil {
+		t.Fatalf("NewGame: %v", err)
+	}
+	return dg
+}
+
+func TestSeededRollDice(t *testing.T) {
+	roll := func(dg *DiceGame) [3]int {
+		if e := dg.RollDice(diceturn.AllDice); e != nil {
+			t.Fatalf("Failed first roll: %v", e)
+		}
+		return dg.CurrentTurn().Rolls[0].RollResults
+	}
+
+	g1 := newGame(t, "G1", "Alpha", "Beta", "Gamma")
+	g1.SetDiceSource(NewSeededSource(42))
+	g2 := newGame(t, "G2", "Alpha", "Beta", "Gamma")
+	g2.SetDiceSource(NewSeededSource(42))
+
+	r1, r2 := roll(g1), roll(g2)
+	if r1 != r2 {
+		t.Errorf("Same seed gave different rolls: %v vs %v", r1, r2)
+	}
+	for _, v := range r1 {
+		if v < 1 || v > 6 {
+			t.Errorf("Bogus die value %d in %v", v, r1)
+		}
+	}
+	if g1.SourceKind != SourceSeeded || g1.Seed != 42 || g1.Draws != 3 {
+		t.Errorf("Source not recorded: %s/%d/%d", g1.SourceKind, g1.Seed, g1.Draws)
+	}
+}
+
+func TestSeededResume(t *testing.T) {
+	g1 := newGame(t, "G1", "Alpha", "Beta", "Gamma")
+	g1.SetDiceSource(NewSeededSource(7))
+	if e := g1.RollDice(diceturn.AllDice); e != nil {
+		t.Fatalf("Failed first roll: %v", e)
+	}
+
+	// Decode a copy mid-turn; it should draw the same dice as the original
+	buf, err := json.Marshal(g1)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	var g2 DiceGame
+	if err := json.Unmarshal(buf, &g2); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+
+	src := NewSeededSource(7)
+	for i := 0; i < 3; i++ {
+		src.Roll()
+	}
+	want := src.Roll()
+	g2.source = nil
+	if e := g2.RollDice(diceturn.Die2); e != nil {
+		t.Fatalf("Failed second roll: %v", e)
+	}
+	if got := g2.CurrentTurn().Rolls[1].RollResults[2]; got != want {
+		t.Errorf("Resumed game rolled %d, expected %d", got, want)
+	}
+}
+
+func TestRollDiceBadMap(t *testing.T) {
+	dg := newGame(t, "G1", "Alpha", "Beta", "Gamma")
+	if e := dg.RollDice(0x08); e == nil {
+		t.Errorf("Allowed roll of a fourth die")
+	}
+	if e := dg.RollDice(0); e == nil {
+		t.Errorf("Allowed roll of no dice")
+	}
+}
+
+func TestRollWithTripleFivePickup(t *testing.T) {
+	dg := newGame(t, "G1", "Alpha", "Beta", "Gamma")
+	for _, r := range [][3]int{{1, 2, 4}, {0, 2, 5}} {
+		if e := dg.RollWith(r[0], r[1], r[2]); e != nil {
+			t.Fatalf("Roll %v failed: %v", r, e)
+		}
+	}
+
+	// Not going for fives: can't pick up the kept 1
+	if e := dg.RollWith(3, 0, 4); e == nil {
+		t.Errorf("Allowed pickup of a kept die leaving a 2 on the table")
+	}
+	// Keep the 5, pick up the 1 and the 2
+	if e := dg.RollWith(3, 4, 0); e != nil {
+		t.Fatalf("Disallowed triple-five pickup: %v", e)
+	}
+
+	dt := dg.CurrentTurn()
+	if dt.Rolls[0].Kept != diceturn.Die0 {
+		t.Errorf("Roll 1 kept 0b%03b, expected 0b%03b", dt.Rolls[0].Kept, diceturn.Die0)
+	}
+	if dt.Rolls[1].Kept != diceturn.Die2 {
+		t.Errorf("Roll 2 kept 0b%03b, expected 0b%03b", dt.Rolls[1].Kept, diceturn.Die2)
+	}
+	if dt.Rolls[2].Rolled != diceturn.Die0|diceturn.Die1 {
+		t.Errorf("Roll 3 rolled 0b%03b, expected 0b%03b", dt.Rolls[2].Rolled, diceturn.Die0|diceturn.Die1)
+	}
+	if dt.Rolls[2].RollResults != [3]int{3, 4, 5} {
+		t.Errorf("Roll 3 results %v, expected [3 4 5]", dt.Rolls[2].RollResults)
+	}
+}
+
+func TestPassDiceSettles(t *testing.T) {
+	dg := newGame(t, "G1", "Alpha", "Beta", "Gamma")
+	if e := dg.RollWith(1, 2, 4); e != nil {
+		t.Fatalf("Roll failed: %v", e)
+	}
+	st, err := dg.PassDice("Beta")
+	if err != nil {
+		t.Fatalf("Pass failed: %v", err)
+	}
+	if st.Loser != "" || !st.Beat {
+		t.Errorf("Alpha's 7 should beat the opening: %v", st)
+	}
+
+	if e := dg.RollWith(3, 4, 6); e != nil {
+		t.Fatalf("Roll failed: %v", e)
+	}
+	if st, err = dg.PassDice("Gamma"); err != nil {
+		t.Fatalf("Pass failed: %v", err)
+	}
+	if st.Loser != "Beta" || st.Against != "Alpha" || st.Marks != 1 {
+		t.Errorf("Beta's 7 tying Alpha's 7 should cost Beta 1: %v", st)
+	}
+	if c := dg.Scores["Beta"].Chevrons[0].Count; c != 1 {
+		t.Errorf("Beta has %d marks, expected 1", c)
+	}
+
+	if _, err = dg.PassDice("Alpha"); err == nil {
+		t.Errorf("Passed the dice without rolling")
+	}
+	if _, err = dg.PassDice("Nobody"); err == nil {
+		t.Errorf("Passed the dice to a stranger")
+	}
+}
+
+func TestRollMarks(t *testing.T) {
+	dg := newGame(t, "G1", "Alpha", "Beta", "Gamma")
+	if e := dg.RollWith(3, 1, 2); e != nil {
+		t.Fatalf("Roll failed: %v", e)
+	}
+	for player, want := range map[string]int32{"Alpha": 0, "Beta": 2, "Gamma": 2} {
+		if c := dg.Scores[player].Chevrons[0].Count; c != want {
+			t.Errorf("%s has %d marks after Alpha's consecutives, expected %d", player, c, want)
+		}
+	}
+	if m := dg.CurrentTurn().Rolls[0].Marks; len(m) != 2 {
+		t.Errorf("Consecutives marks not recorded in roll: %v", m)
+	}
+
+	// Die 1 goes off the table; the seeded source rerolls it
+	dg.SetDiceSource(NewSeededSource(3))
+	want := NewSeededSource(3).Roll()
+	if e := dg.RollWithOff(diceturn.Die1, 0, 6, 4); e != nil {
+		t.Fatalf("Roll failed: %v", e)
+	}
+	dr := dg.CurrentTurn().Rolls[1]
+	if dr.OffTable != diceturn.Die1 || dr.RollResults[1] != want {
+		t.Errorf("Off-table die not rerolled: %v (expected %d)", dr, want)
+	}
+	if c := dg.Scores["Alpha"].Chevrons[0].Count; c < 1 {
+		t.Errorf("Alpha took no penalty for a die off the table")
+	}
+
+	if e := dg.RollWithOff(diceturn.Die0, 0, 0, 5); e == nil {
+		t.Errorf("Allowed a kept die to leave the table")
+	}
+}
+
+func TestScorecardChevrons(t *testing.T) {
+	dg := newGame(t, "G1", "Alpha", "Beta", "Gamma")
+	if e := dg.addMarks(diceturn.Marking{Player: "Beta", Marks: 23}); e != nil {
+		t.Fatalf("Marking failed: %v", e)
+	}
+	if e := dg.NewRound(); e != nil {
+		t.Fatalf("Couldn't start a new round: %v", e)
+	}
+	if n := len(dg.Scores["Beta"].Chevrons); n != 2 {
+		t.Errorf("Beta has %d chevrons after filling one, expected 2", n)
+	}
+	if e := dg.PayChevron("Beta", 0); e != nil {
+		t.Errorf("Couldn't pay: %v", e)
+	}
+
+	sc := dg.Scorecard()
+	t.Logf("Scorecard:\n%s", sc)
+	if lines := strings.Split(strings.TrimSuffix(sc, "\n"), "\n"); len(lines) != 4 {
+		t.Errorf("Scorecard has %d lines, expected header, rule and two chevrons", len(lines))
+	}
+	if !strings.Contains(sc, "$") {
+		t.Errorf("Paid chevron not shown")
+	}
+}
+
+func TestGameOver(t *testing.T) {
+	dg := newGame(t, "G1", "Alpha", "Beta", "Gamma")
+	if e := dg.NewRound(); e == nil {
+		t.Errorf("Started a new round with nobody's chevron filled")
+	}
+	if e := dg.addMarks(diceturn.Marking{Player: "Gamma", Marks: 19}); e != nil {
+		t.Fatalf("Marking failed: %v", e)
+	}
+
+	// Alpha's consecutives put Gamma over the top
+	if e := dg.RollWith(4, 5, 6); e != nil {
+		t.Fatalf("Roll failed: %v", e)
+	}
+	if !dg.Over || dg.Loser != "Gamma" {
+		t.Fatalf("Round should be over with Gamma paying: over %v, loser %s", dg.Over, dg.Loser)
+	}
+	if m := dg.CurrentTurn().Rolls[0].Marks; len(m) != 2 {
+		t.Errorf("Expected consecutives for both others recorded, got %v", m)
+	}
+
+	if e := dg.RollWith(0, 0, 1); !IsGameOver(e) {
+		t.Errorf("Rolled after the round was over (%v)", e)
+	}
+	if _, e := dg.PassDice("Beta"); !IsGameOver(e) {
+		t.Errorf("Passed after the round was over (%v)", e)
+	}
+
+	buf, err := json.Marshal(dg)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	var back DiceGame
+	if err := json.Unmarshal(buf, &back); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if !back.Over || back.Loser != "Gamma" || back.Round != 1 {
+		t.Errorf("End state lost in JSON: over %v, loser %s, round %d", back.Over, back.Loser, back.Round)
+	}
+
+	if e := dg.NewRound(); e != nil {
+		t.Fatalf("Couldn't start a new round: %v", e)
+	}
+	if dg.Round != 2 || dg.Over || dg.CurrentTurn().Player != "Gamma" || dg.PrevTurn() != nil {
+		t.Errorf("New round not set up: %s", dg.GameStatus())
+	}
+	if e := dg.RollWith(1, 2, 2); e != nil {
+		t.Errorf("Couldn't roll in the new round: %v", e)
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	dg := newGame(t, "G1", "Alpha", "Beta", "Gamma")
+	if e := dg.RollWith(3, 1, 2); e != nil {
+		t.Fatalf("Roll failed: %v", e)
+	}
+	if _, e := dg.PassDice("Beta"); e != nil {
+		t.Fatalf("Pass failed: %v", e)
+	}
+	s := dg.Snapshot()
+	if s.PrevTurn() != &s.Turns[0] || s.CurPlayer() != "Beta" {
+		t.Errorf("Snapshot isn't of Beta rolling against Alpha")
+	}
+
+	// The game goes on; the snapshot doesn't
+	if e := dg.RollWith(1, 2, 3); e != nil {
+		t.Fatalf("Roll failed: %v", e)
+	}
+	if n := s.CurrentTurn().NumRolls; n != 0 {
+		t.Errorf("Snapshot turn has %d rolls", n)
+	}
+	if c := s.Scores["Alpha"].Chevrons[0].Count; c != 0 {
+		t.Errorf("Snapshot has Alpha with %d marks", c)
+	}
+	if len(s.Log) == len(dg.Log) {
+		t.Errorf("Snapshot log grew with the game")
+	}
+}
+
+func TestOdds(t *testing.T) {
+	dg := newGame(t, "G1", "Alpha", "Beta")
+	if e := dg.RollWith(2, 2, 5); e != nil {
+		t.Fatalf("Roll failed: %v", e)
+	}
+	cs, err := dg.Odds()
+	if err != nil {
+		t.Fatalf("Odds: %v", err)
+	}
+	// 9 beats the opening 14 already
+	if last := cs[len(cs)-1]; cs[0].Beat != 1 || last.Beat > cs[0].Beat {
+		t.Errorf("Odds against the opening: %v", cs)
+	}
+	for _, c := range cs {
+		if c.ToRoll != 0 && dg.CurrentTurn().RollCheckWith(dg.Rules, c.ToRoll) != nil {
+			t.Errorf("Odds for a move that isn't allowed: %v", c)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package dicegame
+
+import (
+	crand "crypto/rand"
+	"fmt"
+	"math/big"
+	"math/rand"
+)
+
+// Kinds of dice sources, as recorded in a game
+const (
+	SourceCrypto = "crypto"
+	SourceSeeded = "seeded"
+)
+
+// DiceSource - something that produces die values, 1..6
+type DiceSource interface {
+	Roll() int
+	Kind() string
+	Seed() int64
+}
+
+type cryptoSource struct{}
+
+// NewCryptoSource - a source for live play; not reproducible
+func NewCryptoSource() DiceSource {
+	return cryptoSource{}
+}
+
+func (cs cryptoSource) Roll() int {
+	n, err := crand.Int(crand.Reader, big.NewInt(6))
+	if err != nil {
+		panic(fmt.Sprintf("crypto dice source failed: %v", err))
+	}
+	return int(n.Int64()) + 1
+}
+
+func (cs cryptoSource) Kind() string { return SourceCrypto }
+func (cs cryptoSource) Seed() int64  { return 0 }
+
+type seededSource struct {
+	seed int64
+	rng  *rand.Rand
+}
+
+// NewSeededSource - a PRNG source for tests and replays; the same seed always
+// produces the same sequence of rolls
+func NewSeededSource(seed int64) DiceSource {
+	return &seededSource{seed: seed, rng: rand.New(rand.NewSource(seed))}
+}
+
+func (ss *seededSource) Roll() int {
+	return ss.rng.Intn(6) + 1
+}
+
+func (ss *seededSource) Kind() string { return SourceSeeded }
+func (ss *seededSource) Seed() int64  { return ss.seed }
+
+// sourceFor - rebuild a source from what's recorded in a game, skipping the
+// dice already drawn so a seeded game picks up where it left off
+func sourceFor(kind string, seed int64, draws int) (DiceSource, error) {
+	switch kind {
+	case "", SourceCrypto:
+		return NewCryptoSource(), nil
+	case SourceSeeded:
+		src := NewSeededSource(seed)
+		for i := 0; i < draws; i++ {
+			src.Roll()
+		}
+		return src, nil
+	}
+	return nil, fmt.Errorf("unknown dice source kind \"%s\"", kind)
+}
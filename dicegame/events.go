@@ -0,0 +1,110 @@
+package dicegame
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"wojones.com/src/diceturn"
+)
+
+// Kinds of game events
+const (
+	EventRoll     = "roll"
+	EventKeep     = "keep"
+	EventPass     = "pass"
+	EventMark     = "mark"
+	EventPaid     = "paid"
+	EventGameOver = "game_over"
+	EventNewRound = "new_round"
+	EventUndo     = "undo"
+	EventRedo     = "redo"
+	EventJoin     = "join"
+	EventLeave    = "leave"
+	EventStandIn  = "stand_in"
+)
+
+// Event - something that happened in a game, as published to subscribers
+type Event struct {
+	Kind       string               `json:"kind"`
+	GameID     string               `json:"game_id"`
+	Round      int                  `json:"round"`
+	Player     string               `json:"player,omitempty"`
+	Roll       *diceturn.DiceRoll   `json:"roll,omitempty"`
+	Kept       int                  `json:"kept,omitempty"`
+	Marking    *diceturn.Marking    `json:"marking,omitempty"`
+	Settlement *diceturn.Settlement `json:"settlement,omitempty"`
+	Text       string               `json:"text"`
+	Status     string               `json:"status"`
+	When       time.Time            `json:"when"`
+}
+
+// eventBufSize - how far a subscriber can fall behind before it misses events
+const eventBufSize = 64
+
+// EventBus - fan out events to any number of subscribers. A subscriber that
+// doesn't keep up misses events rather than holding up the game.
+type EventBus struct {
+	mu   sync.Mutex
+	subs map[int]chan Event
+	next int
+}
+
+func NewEventBus() *EventBus {
+	return &EventBus{subs: map[int]chan Event{}}
+}
+
+// Subscribe - get a channel of events, and a function to call when done
+func (eb *EventBus) Subscribe() (<-chan Event, func()) {
+	eb.mu.Lock()
+	defer eb.mu.Unlock()
+	id := eb.next
+	eb.next++
+	ch := make(chan Event, eventBufSize)
+	eb.subs[id] = ch
+
+	return ch, func() {
+		eb.mu.Lock()
+		defer eb.mu.Unlock()
+		if c, ok := eb.subs[id]; ok {
+			delete(eb.subs, id)
+			close(c)
+		}
+	}
+}
+
+// Publish - send an event to everyone subscribed
+func (eb *EventBus) Publish(ev Event) {
+	eb.mu.Lock()
+	defer eb.mu.Unlock()
+	for id, ch := range eb.subs {
+		select {
+		case ch <- ev:
+		default:
+			fmt.Printf("EventBus: subscriber %d is behind, dropped %s\n", id, ev.Kind)
+		}
+	}
+}
+
+// Subscribe - follow the events of the game
+func (dg *DiceGame) Subscribe() (<-chan Event, func()) {
+	dg.mu.Lock()
+	defer dg.mu.Unlock()
+	return dg.events().Subscribe()
+}
+
+func (dg *DiceGame) events() *EventBus {
+	if dg.bus == nil {
+		dg.bus = NewEventBus()
+	}
+	return dg.bus
+}
+
+// publish - fill in the game's part of an event and send it
+func (dg *DiceGame) publish(ev Event) {
+	ev.GameID = dg.ID
+	ev.Round = dg.Round
+	ev.Status = dg.GameStatus()
+	ev.When = time.Now()
+	dg.events().Publish(ev)
+}
@@ -0,0 +1,91 @@
+package dicegame
+
+import (
+	"testing"
+
+	"wojones.com/src/diceturn"
+)
+
+// drain - everything published so far
+func drain(evs <-chan Event) []Event {
+	got := []Event{}
+	for {
+		select {
+		case ev := <-evs:
+			got = append(got, ev)
+		default:
+			return got
+		}
+	}
+}
+
+func kinds(evs []Event) []string {
+	ks := []string{}
+	for _, ev := range evs {
+		ks = append(ks, ev.Kind)
+	}
+	return ks
+}
+
+func TestGameEvents(t *testing.T) {
+	dg := newGame(t, "G1", "Alpha", "Beta", "Gamma")
+	evs, done := dg.Subscribe()
+	defer done()
+
+	if e := dg.RollWith(1, 2, 4); e != nil {
+		t.Fatalf("Roll failed: %v", e)
+	}
+	if e := dg.RollWith(0, 2, 3); e != nil {
+		t.Fatalf("Roll failed: %v", e)
+	}
+	got := drain(evs)
+	want := []string{EventRoll, EventKeep, EventRoll, EventMark, EventMark}
+	if len(got) != len(want) {
+		t.Fatalf("Got events %v, expected %v", kinds(got), want)
+	}
+	for i := range want {
+		if got[i].Kind != want[i] || got[i].GameID != "G1" {
+			t.Errorf("Event %d is %s in %s, expected %s in G1", i, got[i].Kind, got[i].GameID, want[i])
+		}
+	}
+	if got[1].Kept != diceturn.Die0 {
+		t.Errorf("Keep event kept 0b%03b, expected 0b%03b", got[1].Kept, diceturn.Die0)
+	}
+	if got[2].Roll == nil || got[2].Roll.RollResults != [3]int{1, 2, 3} {
+		t.Errorf("Roll event doesn't have the roll: %v", got[2].Roll)
+	}
+
+	if _, e := dg.PassDice("Beta"); e != nil {
+		t.Fatalf("Pass failed: %v", e)
+	}
+	got = drain(evs)
+	if len(got) != 1 || got[0].Kind != EventPass || got[0].Settlement == nil || got[0].Player != "Beta" {
+		t.Errorf("Expected a pass to Beta, got %v", got)
+	}
+
+	// Fill Gamma's chevron
+	if e := dg.addMarks(diceturn.Marking{Player: "Gamma", Marks: 30}); e != nil {
+		t.Fatalf("Marking failed: %v", e)
+	}
+	got = drain(evs)
+	if len(got) != 2 || got[1].Kind != EventGameOver || got[1].Player != "Gamma" {
+		t.Errorf("Expected a mark and game over, got %v", kinds(got))
+	}
+
+	done()
+	if _, ok := <-evs; ok {
+		t.Errorf("Channel still open after unsubscribing")
+	}
+}
+
+func TestEventBusSlowSubscriber(t *testing.T) {
+	eb := NewEventBus()
+	slow, done := eb.Subscribe()
+	defer done()
+	for i := 0; i < eventBufSize+10; i++ {
+		eb.Publish(Event{Kind: EventRoll})
+	}
+	if n := len(drain(slow)); n != eventBufSize {
+		t.Errorf("Slow subscriber got %d events, expected %d", n, eventBufSize)
+	}
+}
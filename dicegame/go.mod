@@ -6,9 +6,18 @@ replace wojones.com/src/dicescore => ../dicescore
 
 replace wojones.com/src/diceturn => ../diceturn
 
+replace wojones.com/src/diceodds => ../diceodds
+
+replace wojones.com/src/simulate => ../simulate
+
 require (
+	wojones.com/src/diceodds v0.0.0-00010101000000-000000000000
 	wojones.com/src/dicescore v0.0.0-00010101000000-000000000000
 	wojones.com/src/diceturn v0.0.0-00010101000000-000000000000
+	wojones.com/src/simulate v0.0.0-00010101000000-000000000000
 )
 
-require golang.org/x/exp v0.0.0-20220602145555-4a0574d9293f // indirect
+require (
+	golang.org/x/exp v0.0.0-20220602145555-4a0574d9293f
+	gopkg.in/yaml.v2 v2.4.0 // indirect
+)
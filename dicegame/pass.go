@@ -0,0 +1,84 @@
+package dicegame
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/exp/slices"
+	"wojones.com/src/diceturn"
+)
+
+// Why the dice can't be passed; a PassError wraps one of these
+var (
+	ErrNotRolled    = errors.New("nothing's been rolled")
+	ErrPassToSelf   = errors.New("that's who's rolling")
+	ErrNoSuchPlayer = errors.New("no such player")
+	ErrOutOfTurn    = errors.New("the dice go round the table the other way")
+)
+
+// PassError - the dice can't go From one player To another. Legal is who
+// they could go to.
+type PassError struct {
+	From   string
+	To     string
+	Reason error
+	Legal  []string
+}
+
+func (e *PassError) Error() string {
+	return fmt.Sprintf("%s can't pass to %s: %v", e.From, e.To, e.Reason)
+}
+
+func (e *PassError) Unwrap() error {
+	return e.Reason
+}
+
+// PassTargets - who the roller can pass the dice to once they've rolled
+func (dg DiceGame) PassTargets() []string {
+	n := len(dg.Players)
+	if dg.Rules.PassMode == diceturn.PassRotation {
+		if dg.Direction != 0 {
+			return []string{dg.Players[(dg.Cur+dg.Direction+n)%n]}
+		}
+		// Either way round; with two players that's the same player
+		left, right := dg.Players[(dg.Cur+1)%n], dg.Players[(dg.Cur+n-1)%n]
+		if left == right {
+			return []string{left}
+		}
+		return []string{left, right}
+	}
+	others := make([]string, 0, n-1)
+	for idx, player := range dg.Players {
+		if idx != dg.Cur {
+			others = append(others, player)
+		}
+	}
+	return others
+}
+
+// passCheck - can the dice go to player?
+func (dg *DiceGame) passCheck(player string) error {
+	pe := &PassError{From: dg.CurPlayer(), To: player, Legal: dg.PassTargets()}
+	switch {
+	case dg.Turns[len(dg.Turns)-1].NumRolls == 0:
+		pe.Reason = ErrNotRolled
+	case player == pe.From:
+		pe.Reason = ErrPassToSelf
+	case !slices.Contains(dg.Players, player):
+		pe.Reason = ErrNoSuchPlayer
+	case !slices.Contains(pe.Legal, player):
+		pe.Reason = ErrOutOfTurn
+	default:
+		return nil
+	}
+	return pe
+}
+
+// passDirection - which way round the table a pass to idx goes: 1 in the
+// order of Players, -1 against it
+func (dg *DiceGame) passDirection(idx int) int {
+	if idx == (dg.Cur+1)%len(dg.Players) {
+		return 1
+	}
+	return -1
+}
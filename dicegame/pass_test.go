@@ -0,0 +1,80 @@
+package dicegame
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"wojones.com/src/diceturn"
+)
+
+func TestPassErrors(t *testing.T) {
+	dg := newGame(t, "G1", "Alpha", "Beta", "Gamma")
+	if _, e := dg.PassDice("Beta"); !errors.Is(e, ErrNotRolled) {
+		t.Errorf("Passing before rolling: %v", e)
+	}
+	if e := dg.RollWith(1, 2, 4); e != nil {
+		t.Fatalf("Roll failed: %v", e)
+	}
+	if _, e := dg.PassDice("Alpha"); !errors.Is(e, ErrPassToSelf) {
+		t.Errorf("Passing to self: %v", e)
+	}
+	_, e := dg.PassDice("Nobody")
+	var pe *PassError
+	if !errors.As(e, &pe) || pe.Reason != ErrNoSuchPlayer || pe.From != "Alpha" || pe.To != "Nobody" {
+		t.Fatalf("Passing to a stranger: %v", e)
+	}
+	if strings.Join(pe.Legal, " ") != "Beta Gamma" {
+		t.Errorf("Legal targets %v, expected Beta and Gamma", pe.Legal)
+	}
+	if n := len(dg.Turns); n != 1 {
+		t.Errorf("Failed passes left %d turns", n)
+	}
+	if _, e := dg.PassDice("Gamma"); e != nil {
+		t.Errorf("Pass failed: %v", e)
+	}
+}
+
+func TestPassRotation(t *testing.T) {
+	dg := newGame(t, "G1", "Alpha", "Beta", "Gamma", "Delta")
+	rules := diceturn.DefaultRules()
+	rules.PassMode = diceturn.PassRotation
+	if e := dg.SetRules(rules); e != nil {
+		t.Fatalf("SetRules: %v", e)
+	}
+	if got := strings.Join(dg.PassTargets(), " "); got != "Beta Delta" {
+		t.Errorf("Alpha can pass to %s, expected either neighbour", got)
+	}
+	if e := dg.RollWith(1, 2, 4); e != nil {
+		t.Fatalf("Roll failed: %v", e)
+	}
+	if _, e := dg.PassDice("Gamma"); !errors.Is(e, ErrOutOfTurn) {
+		t.Errorf("Passed across the table: %v", e)
+	}
+	// First pass of the round goes right; the dice keep going that way
+	if _, e := dg.PassDice("Delta"); e != nil {
+		t.Fatalf("Pass failed: %v", e)
+	}
+	if dg.Direction != -1 {
+		t.Errorf("Direction %d after passing right", dg.Direction)
+	}
+	if e := dg.RollWith(1, 2, 5); e != nil {
+		t.Fatalf("Roll failed: %v", e)
+	}
+	if _, e := dg.PassDice("Alpha"); !errors.Is(e, ErrOutOfTurn) {
+		t.Errorf("Passed back the way the dice came: %v", e)
+	}
+	if _, e := dg.PassDice("Gamma"); e != nil {
+		t.Errorf("Pass failed: %v", e)
+	}
+
+	// Undoing the first pass forgets the direction
+	for i := 0; i < 3; i++ {
+		if e := dg.Undo(); e != nil {
+			t.Fatalf("Undo: %v", e)
+		}
+	}
+	if dg.Direction != 0 || dg.CurPlayer() != "Alpha" {
+		t.Errorf("Direction %d with %s rolling after undoing", dg.Direction, dg.CurPlayer())
+	}
+}
@@ -0,0 +1,120 @@
+package dicegame
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/exp/slices"
+	"wojones.com/src/dicescore"
+)
+
+// betweenTurns - players come and go only while nobody's rolling: before the
+// player with the dice has rolled, or once the round is over
+func (dg *DiceGame) betweenTurns() error {
+	if !dg.Over && dg.Turns[len(dg.Turns)-1].NumRolls > 0 {
+		return fmt.Errorf("%s is rolling; wait until the dice are passed", dg.CurPlayer())
+	}
+	return nil
+}
+
+// AddPlayer - someone joins the game, sitting last at the table. A player
+// who left and comes back picks up their old scorecard.
+func (dg *DiceGame) AddPlayer(player string) error {
+	dg.mu.Lock()
+	defer dg.mu.Unlock()
+	return dg.saved(dg.addPlayer(player))
+}
+
+func (dg *DiceGame) addPlayer(player string) error {
+	if err := dg.betweenTurns(); err != nil {
+		return err
+	}
+	if len(dg.Players) >= MaxPlayers {
+		return fmt.Errorf("the table is full at %d players", MaxPlayers)
+	}
+	if err := checkName(player, dg.Players); err != nil {
+		return err
+	}
+	dg.Players = append(dg.Players, player)
+	if _, ok := dg.Scores[player]; !ok {
+		dg.Scores[player] = dicescore.NewPlayerScore(player)
+	}
+	dg.record(Action{Kind: ActAddPlayer, Player: player})
+	dg.publish(Event{Kind: EventJoin, Player: player,
+		Text: fmt.Sprintf("%s joins the game", player)})
+	return nil
+}
+
+// RemovePlayer - someone leaves the game. Not the player with the dice, nor
+// the one whose turn is to be beaten, nor the loser of a round that's over:
+// they're needed to finish what's going on. Their scorecard is kept.
+func (dg *DiceGame) RemovePlayer(player string) error {
+	dg.mu.Lock()
+	defer dg.mu.Unlock()
+	return dg.saved(dg.removePlayer(player))
+}
+
+func (dg *DiceGame) removePlayer(player string) error {
+	if err := dg.betweenTurns(); err != nil {
+		return err
+	}
+	idx := slices.Index(dg.Players, player)
+	switch {
+	case idx < 0:
+		return fmt.Errorf("no player %s", player)
+	case len(dg.Players) <= MinPlayers:
+		return fmt.Errorf("can't play with fewer than %d players", MinPlayers)
+	case idx == dg.Cur:
+		return fmt.Errorf("%s has the dice; pass them first", player)
+	case idx == dg.Prev:
+		return fmt.Errorf("%s's turn is the one to beat", player)
+	case dg.Over && player == dg.Loser:
+		return fmt.Errorf("%s lost the round and starts the next one", player)
+	}
+
+	dg.Players = append(dg.Players[:idx:idx], dg.Players[idx+1:]...)
+	delete(dg.Bots, player)
+	if idx < dg.Cur {
+		dg.Cur--
+	}
+	if idx < dg.Prev {
+		dg.Prev--
+	}
+	dg.record(Action{Kind: ActRemovePlayer, Player: player})
+	dg.publish(Event{Kind: EventLeave, Player: player,
+		Text: fmt.Sprintf("%s leaves the game", player)})
+	return nil
+}
+
+// StandIn - someone rolls on behalf of the player with the dice, for this
+// turn only: a community player stepping in, say. The turn, and any marks
+// that come with it, are still the player's. An empty roller, or the player
+// themselves, means nobody's standing in. The stand-in has to be settled
+// before the first roll.
+func (dg *DiceGame) StandIn(roller string) error {
+	dg.mu.Lock()
+	defer dg.mu.Unlock()
+	return dg.saved(dg.standIn(roller))
+}
+
+func (dg *DiceGame) standIn(roller string) error {
+	if err := dg.overErr(); err != nil {
+		return err
+	}
+	tp := &dg.Turns[len(dg.Turns)-1]
+	if tp.NumRolls > 0 {
+		return fmt.Errorf("%s has already rolled; a stand-in has to start the turn", tp.Who())
+	}
+	roller = strings.TrimSpace(roller)
+	if roller == tp.Player {
+		roller = ""
+	}
+	tp.RolledBy = roller
+	dg.record(Action{Kind: ActStandIn, Player: roller})
+	text := fmt.Sprintf("%s rolls for themselves", tp.Player)
+	if roller != "" {
+		text = fmt.Sprintf("%s rolls for %s", roller, tp.Player)
+	}
+	dg.publish(Event{Kind: EventStandIn, Player: tp.Player, Text: text})
+	return nil
+}
@@ -0,0 +1,163 @@
+package dicegame
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestNewGamePlayers(t *testing.T) {
+	tests := []struct {
+		name    string
+		players []string
+		wantErr bool
+	}{
+		{"two", []string{"Alpha", "Beta"}, false},
+		{"eight", []string{"A", "B", "C", "D", "E", "F", "G", "H"}, false},
+		{"one", []string{"Alpha"}, true},
+		{"nine", []string{"A", "B", "C", "D", "E", "F", "G", "H", "I"}, true},
+		{"twice", []string{"Alpha", "Beta", "Alpha"}, true},
+		{"nameless", []string{"Alpha", " "}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dg, err := NewGame("G1", tt.players...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewGame(%v) error = %v, wantErr %v", tt.players, err, tt.wantErr)
+			}
+			if err == nil && len(dg.Scores) != len(tt.players) {
+				t.Errorf("%d scores for %d players", len(dg.Scores), len(tt.players))
+			}
+		})
+	}
+}
+
+func TestTwoPlayers(t *testing.T) {
+	dg := newGame(t, "G1", "Alpha", "Beta")
+	if e := dg.RollWith(3, 1, 2); e != nil {
+		t.Fatalf("Roll failed: %v", e)
+	}
+	if c := dg.Scores["Beta"].Chevrons[0].Count; c != 2 {
+		t.Errorf("Beta has %d marks for Alpha's consecutives, expected 2", c)
+	}
+	if _, e := dg.PassDice("Beta"); e != nil {
+		t.Fatalf("Pass failed: %v", e)
+	}
+	if dg.CurPlayer() != "Beta" || dg.PrevPlayer() != "Alpha" {
+		t.Errorf("Expected Beta against Alpha: %s", dg.CurTurn())
+	}
+	if sc := dg.Scorecard(); strings.Count(strings.SplitN(sc, "\n", 2)[0], "|") != 1 {
+		t.Errorf("Scorecard doesn't have two columns:\n%s", sc)
+	}
+}
+
+func TestAddRemovePlayer(t *testing.T) {
+	dg := newGame(t, "G1", "Alpha", "Beta", "Gamma")
+	if e := dg.RollWith(1, 2, 4); e != nil {
+		t.Fatalf("Roll failed: %v", e)
+	}
+	if e := dg.AddPlayer("Delta"); e == nil {
+		t.Errorf("Joined while Alpha was rolling")
+	}
+	if _, e := dg.PassDice("Beta"); e != nil {
+		t.Fatalf("Pass failed: %v", e)
+	}
+
+	if e := dg.AddPlayer("Delta"); e != nil {
+		t.Fatalf("AddPlayer: %v", e)
+	}
+	if e := dg.AddPlayer("Delta"); e == nil {
+		t.Errorf("Delta joined twice")
+	}
+	for _, p := range []string{"Alpha", "Beta", "Nobody"} {
+		if e := dg.RemovePlayer(p); e == nil {
+			t.Errorf("Removed %s", p)
+		}
+	}
+	if e := dg.RemovePlayer("Gamma"); e != nil {
+		t.Fatalf("RemovePlayer: %v", e)
+	}
+	if strings.Join(dg.Players, " ") != "Alpha Beta Delta" || dg.CurPlayer() != "Beta" {
+		t.Errorf("Players now %v, %s rolling", dg.Players, dg.CurPlayer())
+	}
+	if _, ok := dg.Scores["Gamma"]; !ok {
+		t.Errorf("Gamma's scorecard went with them")
+	}
+
+	// Beta's consecutives mark everyone still playing
+	if e := dg.RollWith(4, 5, 6); e != nil {
+		t.Fatalf("Roll failed: %v", e)
+	}
+	for player, want := range map[string]int32{"Alpha": 2, "Delta": 2, "Gamma": 0} {
+		if c := dg.Scores[player].Chevrons[0].Count; c != want {
+			t.Errorf("%s has %d marks, expected %d", player, c, want)
+		}
+	}
+	if _, e := dg.PassDice("Gamma"); e == nil {
+		t.Errorf("Passed to a player who left")
+	}
+	if _, e := dg.PassDice("Delta"); e != nil {
+		t.Fatalf("Pass failed: %v", e)
+	}
+
+	back, err := Replay(dg.Log)
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	want, _ := json.Marshal(dg)
+	got, _ := json.Marshal(back)
+	if string(got) != string(want) {
+		t.Errorf("Replayed game differs:\n%s\nexpected:\n%s", got, want)
+	}
+}
+
+func TestStandIn(t *testing.T) {
+	dg := newGame(t, "G1", "Alpha", "Beta", "Gamma")
+	if e := dg.StandIn("Zed"); e != nil {
+		t.Fatalf("StandIn: %v", e)
+	}
+	if st := dg.GameStatus(); !strings.Contains(st, "Alpha (rolled by Zed)") {
+		t.Errorf("Status doesn't show the stand-in: %s", st)
+	}
+	if sc := dg.Scorecard(); !strings.Contains(sc, "Zed is rolling for Alpha") {
+		t.Errorf("Scorecard doesn't show the stand-in:\n%s", sc)
+	}
+	// Zed rolls two off the table; Alpha takes the marks
+	if e := dg.RollWithOff(0b011, 1, 2, 4); e != nil {
+		t.Fatalf("Roll failed: %v", e)
+	}
+	if c := dg.Scores["Alpha"].Chevrons[0].Count; c != 2 {
+		t.Errorf("Alpha has %d marks, expected 2", c)
+	}
+	if _, ok := dg.Scores["Zed"]; ok {
+		t.Errorf("Stand-in got a scorecard")
+	}
+	if e := dg.StandIn("Beta"); e == nil {
+		t.Errorf("Changed stand-in after rolling")
+	}
+	if _, e := dg.PassDice("Beta"); e != nil {
+		t.Fatalf("Pass failed: %v", e)
+	}
+	if tp := dg.Turns[0]; tp.Player != "Alpha" || tp.RolledBy != "Zed" || tp.Settled.Player != "Alpha" {
+		t.Errorf("Turn %v settled for %s", tp, tp.Settled.Player)
+	}
+	if tp := dg.CurrentTurn(); tp.RolledBy != "" {
+		t.Errorf("Stand-in carried over to %s's turn", tp.Player)
+	}
+
+	// Stand in and stop standing in: the player rolls for themselves
+	if e := dg.StandIn("Gamma"); e != nil {
+		t.Fatalf("StandIn: %v", e)
+	}
+	if e := dg.StandIn("Beta"); e != nil || dg.CurrentTurn().RolledBy != "" {
+		t.Errorf("Beta didn't take the dice back: %v", e)
+	}
+
+	rg, err := Replay(dg.Log)
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if rg.Turns[0].RolledBy != "Zed" {
+		t.Errorf("Replay lost the stand-in: %v", rg.Turns[0])
+	}
+}
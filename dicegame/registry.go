@@ -0,0 +1,143 @@
+package dicegame
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Registry - all the games we know about, by game ID. Safe for use from
+// multiple goroutines.
+type Registry struct {
+	mu       sync.RWMutex
+	games    map[string]*DiceGame
+	archived map[string]*DiceGame
+	store    Store
+}
+
+func NewRegistry() *Registry {
+	return &Registry{games: map[string]*DiceGame{}, archived: map[string]*DiceGame{}}
+}
+
+// OpenRegistry - a registry of the games kept in st, which saves its games
+// there as they're played
+func OpenRegistry(st Store) (*Registry, error) {
+	reg := NewRegistry()
+	reg.store = st
+	for _, archived := range []bool{false, true} {
+		ids, err := st.List(archived)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			dg, err := st.Load(id, archived)
+			if err != nil {
+				return nil, err
+			}
+			if archived {
+				reg.archived[id] = dg
+			} else {
+				dg.store = st
+				reg.games[id] = dg
+			}
+		}
+	}
+	return reg, nil
+}
+
+// Create - start a new game and register it
+func (reg *Registry) Create(ID string, players ...string) (*DiceGame, error) {
+	dg, err := NewGame(ID, players...)
+	if err != nil {
+		return nil, err
+	}
+	if err := reg.Add(dg); err != nil {
+		return nil, err
+	}
+	return dg, nil
+}
+
+// Add - register an existing game
+func (reg *Registry) Add(dg *DiceGame) error {
+	if dg.ID == "" {
+		return fmt.Errorf("game has no ID")
+	}
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	if _, ok := reg.games[dg.ID]; ok {
+		return fmt.Errorf("game %s already exists", dg.ID)
+	}
+	if _, ok := reg.archived[dg.ID]; ok {
+		return fmt.Errorf("game %s already exists (archived)", dg.ID)
+	}
+	if reg.store != nil {
+		if err := reg.store.Save(dg); err != nil {
+			return &SaveError{ID: dg.ID, Err: err}
+		}
+		dg.setStore(reg.store)
+	}
+	reg.games[dg.ID] = dg
+	return nil
+}
+
+// Lookup - find an active game
+func (reg *Registry) Lookup(ID string) (*DiceGame, bool) {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	dg, ok := reg.games[ID]
+	return dg, ok
+}
+
+// LookupArchived - find an archived game
+func (reg *Registry) LookupArchived(ID string) (*DiceGame, bool) {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	dg, ok := reg.archived[ID]
+	return dg, ok
+}
+
+// List - IDs of the active games, sorted
+func (reg *Registry) List() []string {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	return sortedIDs(reg.games)
+}
+
+// ListArchived - IDs of the archived games, sorted
+func (reg *Registry) ListArchived() []string {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	return sortedIDs(reg.archived)
+}
+
+// Archive - retire an active game; it can still be looked up, but it's no
+// longer listed as active
+func (reg *Registry) Archive(ID string) error {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	dg, ok := reg.games[ID]
+	if !ok {
+		return fmt.Errorf("no active game %s", ID)
+	}
+	if reg.store != nil {
+		// Stop saving first, so nobody still playing the game writes it back
+		// among the active ones once it's moved
+		dg.setStore(nil)
+		if err := reg.store.Archive(ID); err != nil {
+			dg.setStore(reg.store)
+			return err
+		}
+	}
+	delete(reg.games, ID)
+	reg.archived[ID] = dg
+	return nil
+}
+
+func sortedIDs(games map[string]*DiceGame) []string {
+	ids := make([]string, 0, len(games))
+	for id := range games {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,79 @@
+package dicegame
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	reg := NewRegistry()
+	if _, err := reg.Create("G1", "Alpha", "Beta", "Gamma"); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := reg.Create("G1", "Delta", "Epsilon", "Zeta"); err == nil {
+		t.Errorf("Created a second G1")
+	}
+	if _, err := reg.Create("G0", "Delta", "Epsilon", "Zeta"); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if ids := reg.List(); len(ids) != 2 || ids[0] != "G0" || ids[1] != "G1" {
+		t.Errorf("List gave %v, expected [G0 G1]", ids)
+	}
+
+	dg, ok := reg.Lookup("G1")
+	if !ok || dg.Players[0] != "Alpha" {
+		t.Fatalf("Lookup of G1 failed")
+	}
+	// Lookup hands back the registered game, not a copy
+	if e := dg.RollWith(1, 2, 4); e != nil {
+		t.Fatalf("Roll failed: %v", e)
+	}
+	if again, _ := reg.Lookup("G1"); again.CurrentTurn().NumRolls != 1 {
+		t.Errorf("Roll didn't stick to the registered game")
+	}
+
+	if err := reg.Archive("G1"); err != nil {
+		t.Fatalf("Archive failed: %v", err)
+	}
+	if err := reg.Archive("G1"); err == nil {
+		t.Errorf("Archived G1 twice")
+	}
+	if _, ok := reg.Lookup("G1"); ok {
+		t.Errorf("Archived game still active")
+	}
+	if _, ok := reg.LookupArchived("G1"); !ok {
+		t.Errorf("Archived game not found")
+	}
+	if _, err := reg.Create("G1", "Delta", "Epsilon", "Zeta"); err == nil {
+		t.Errorf("Reused the ID of an archived game")
+	}
+	if _, ok := reg.Lookup("nope"); ok {
+		t.Errorf("Found a game that doesn't exist")
+	}
+}
+
+func TestRegistryConcurrent(t *testing.T) {
+	reg := NewRegistry()
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			id := fmt.Sprintf("G%02d", i)
+			if _, err := reg.Create(id, "Alpha", "Beta", "Gamma"); err != nil {
+				t.Errorf("Create %s failed: %v", id, err)
+			}
+			reg.List()
+			if i%2 == 0 {
+				if err := reg.Archive(id); err != nil {
+					t.Errorf("Archive %s failed: %v", id, err)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	if n, na := len(reg.List()), len(reg.ListArchived()); n != 10 || na != 10 {
+		t.Errorf("%d active and %d archived, expected 10 of each", n, na)
+	}
+}
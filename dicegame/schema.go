@@ -0,0 +1,116 @@
+package dicegame
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"golang.org/x/exp/slices"
+	"wojones.com/src/diceturn"
+)
+
+// SchemaVersion - the shape of a game's JSON. Games saved before there was a
+// version (version 1) pointed at the players and the previous turn rather
+// than indexing them; they're migrated as they're read.
+const SchemaVersion = 2
+
+// gameV1 - the parts of a version 1 game that are gone from the current one
+type gameV1 struct {
+	PrevPlayer *string            `json:"prev_player"`
+	PrevTurn   *diceturn.DiceTurn `json:"PrevTurn"`
+	CurPlayer  *string            `json:"cur_player"`
+}
+
+// UnmarshalJSON - decode a game of any schema version, bringing it up to
+// the current one
+func (dg *DiceGame) UnmarshalJSON(b []byte) error {
+	type plain DiceGame
+	// Anything from before rounds and house rules played by the defaults
+	p := plain{Round: 1, Rules: diceturn.DefaultRules(), SourceKind: SourceCrypto}
+	if err := json.Unmarshal(b, &p); err != nil {
+		return err
+	}
+	*dg = DiceGame(p)
+	switch {
+	case dg.Version == 0:
+		var v1 gameV1
+		if err := json.Unmarshal(b, &v1); err != nil {
+			return err
+		}
+		if err := dg.migrateV1(v1); err != nil {
+			return fmt.Errorf("game %s: %v", dg.ID, err)
+		}
+	case dg.Version > SchemaVersion:
+		return fmt.Errorf("game %s is version %d; this only knows up to %d", dg.ID, dg.Version, SchemaVersion)
+	}
+	if err := dg.checkRefs(); err != nil {
+		return fmt.Errorf("game %s: %v", dg.ID, err)
+	}
+	dg.bus = NewEventBus()
+	dg.mu = &sync.Mutex{}
+	return nil
+}
+
+// migrateV1 - turn a version 1 game's pointers into indexes. The previous
+// turn is always the one before the current one: a saved copy of it could be
+// stale, so it's only used to tell whether there was one. A game from before
+// there were logs starts one with a snapshot of itself, so that there's
+// something to replay (and undo) from.
+func (dg *DiceGame) migrateV1(v1 gameV1) error {
+	player := func(name *string) (int, error) {
+		if name == nil {
+			return -1, nil
+		}
+		idx := slices.Index(dg.Players, *name)
+		if idx < 0 {
+			return -1, fmt.Errorf("no player %s", *name)
+		}
+		return idx, nil
+	}
+	var err error
+	if v1.CurPlayer == nil && len(dg.Turns) > 0 {
+		v1.CurPlayer = &dg.Turns[len(dg.Turns)-1].Player
+	}
+	if dg.Cur, err = player(v1.CurPlayer); err != nil {
+		return err
+	}
+	if dg.Prev, err = player(v1.PrevPlayer); err != nil {
+		return err
+	}
+	dg.PrevTurnNo = -1
+	if v1.PrevTurn != nil {
+		dg.PrevTurnNo = len(dg.Turns) - 2
+	}
+	dg.Version = SchemaVersion
+	if len(dg.Log) == 0 {
+		g := dg.snapshot()
+		dg.record(Action{Kind: ActNewGame, GameID: dg.ID, Players: slices.Clone(dg.Players)})
+		dg.record(Action{Kind: ActSnapshot, Game: &g})
+	}
+	return nil
+}
+
+// checkRefs - make sure the indexes point at something
+func (dg *DiceGame) checkRefs() error {
+	if len(dg.Turns) == 0 {
+		return fmt.Errorf("no turns")
+	}
+	if dg.Cur < 0 || dg.Cur >= len(dg.Players) {
+		return fmt.Errorf("no player %d rolling", dg.Cur)
+	}
+	if dg.Prev < -1 || dg.Prev >= len(dg.Players) {
+		return fmt.Errorf("no player %d rolled last", dg.Prev)
+	}
+	if dg.PrevTurnNo < -1 || dg.PrevTurnNo >= len(dg.Turns)-1 {
+		return fmt.Errorf("no turn %d to beat", dg.PrevTurnNo)
+	}
+	if dg.Direction < -1 || dg.Direction > 1 {
+		return fmt.Errorf("dice can't go round the table in direction %d", dg.Direction)
+	}
+	for player, name := range dg.Bots {
+		if _, err := StrategyNamed(name); err != nil {
+			return fmt.Errorf("bot %s: %v", player, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package dicegame
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"wojones.com/src/diceturn"
+)
+
+func TestMigrateV1(t *testing.T) {
+	// Saved before schema versions, with PrevTurn left pointing at a stale
+	// copy of Alpha's turn rather than Beta's
+	buf, err := os.ReadFile("testdata/game_v1.json")
+	if err != nil {
+		t.Fatalf("Reading old game: %v", err)
+	}
+	var dg DiceGame
+	if err := json.Unmarshal(buf, &dg); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if dg.Version != SchemaVersion {
+		t.Errorf("Migrated game is version %d", dg.Version)
+	}
+	if dg.CurPlayer() != "Gamma" || dg.PrevPlayer() != "Beta" {
+		t.Errorf("Expected Gamma rolling after Beta, got %s after %s", dg.CurPlayer(), dg.PrevPlayer())
+	}
+	if pt := dg.PrevTurn(); pt == nil || pt.Player != "Beta" || pt.DiceVals != [3]int{3, 4, 6} {
+		t.Errorf("Previous turn should be Beta's 3 4 6, got %v", pt)
+	}
+
+	// Plays on, and saves as the current version
+	if e := dg.RollWith(1, 1, 2); e != nil {
+		t.Fatalf("Roll failed: %v", e)
+	}
+	st, err := dg.PassDice("Alpha")
+	if err != nil {
+		t.Fatalf("Pass failed: %v", err)
+	}
+	if st.Against != "Beta" || !st.Beat {
+		t.Errorf("Gamma's 4 should beat Beta's 7: %v", st)
+	}
+	out, _ := json.Marshal(&dg)
+	var raw map[string]interface{}
+	json.Unmarshal(out, &raw)
+	if _, ok := raw["PrevTurn"]; ok || raw["version"] != float64(SchemaVersion) {
+		t.Errorf("Not saved as version %d: %s", SchemaVersion, out)
+	}
+}
+
+func TestUnmarshalBadGame(t *testing.T) {
+	dg := newGame(t, "G1", "Alpha", "Beta", "Gamma")
+	good, _ := json.Marshal(dg)
+	for name, change := range map[string]func(m map[string]interface{}){
+		"future":      func(m map[string]interface{}) { m["version"] = SchemaVersion + 1 },
+		"cur":         func(m map[string]interface{}) { m["cur"] = 3 },
+		"prev turn":   func(m map[string]interface{}) { m["prev_turn"] = 0 },
+		"v1 stranger": func(m map[string]interface{}) { m["version"] = 0; m["cur_player"] = "Delta" },
+	} {
+		var m map[string]interface{}
+		json.Unmarshal(good, &m)
+		change(m)
+		buf, _ := json.Marshal(m)
+		var back DiceGame
+		if err := json.Unmarshal(buf, &back); err == nil {
+			t.Errorf("%s: decoded a bad game", name)
+		}
+	}
+
+	// Missing house rules are the defaults
+	var back DiceGame
+	if err := json.Unmarshal([]byte(`{"game_id": "G0", "players": ["A", "B", "C"],
+		"turns": [{"Player": "A"}]}`), &back); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if back.Rules != diceturn.DefaultRules() || back.Round != 1 || back.PrevTurn() != nil {
+		t.Errorf("Old game not defaulted: %v, round %d", back.Rules, back.Round)
+	}
+}
+
+func TestUndoMigrated(t *testing.T) {
+	// Saved before games kept logs
+	dir := t.TempDir()
+	buf, err := os.ReadFile("testdata/game_v1.json")
+	if err != nil {
+		t.Fatalf("Reading old game: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "Old1.json"), buf, 0o644); err != nil {
+		t.Fatalf("Writing old game: %v", err)
+	}
+	st, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+	reg, err := OpenRegistry(st)
+	if err != nil {
+		t.Fatalf("OpenRegistry: %v", err)
+	}
+	dg, ok := reg.Lookup("Old1")
+	if !ok {
+		t.Fatalf("Old game not loaded")
+	}
+	before := dg.Snapshot()
+
+	if e := dg.RollWith(1, 1, 2); e != nil {
+		t.Fatalf("Roll failed: %v", e)
+	}
+	if err := dg.Undo(); err != nil {
+		t.Fatalf("Undo: %v", err)
+	}
+	s := dg.Snapshot()
+	if !reflect.DeepEqual(s.Turns, before.Turns) || !reflect.DeepEqual(s.Scores, before.Scores) ||
+		s.CurPlayer() != "Gamma" || s.PrevTurn().Player != "Beta" {
+		t.Errorf("Undo didn't take the game back to how it was loaded: %v", s.Turns)
+	}
+	if err := dg.Undo(); err == nil || !strings.Contains(err.Error(), "log starts") {
+		t.Errorf("Undid past the start of the log: %v", err)
+	}
+
+	// The log it started was saved, so a restart can still redo
+	reg2, err := OpenRegistry(st)
+	if err != nil {
+		t.Fatalf("OpenRegistry: %v", err)
+	}
+	back, _ := reg2.Lookup("Old1")
+	if err := back.Redo(); err != nil {
+		t.Fatalf("Redo after restart: %v", err)
+	}
+	if s := back.Snapshot(); s.CurrentTurn().NumRolls != 1 {
+		t.Errorf("Redo after restart: %v", s.CurrentTurn())
+	}
+}
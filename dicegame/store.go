@@ -0,0 +1,147 @@
+package dicegame
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Store - somewhere to keep games between runs. A registry with a store saves
+// each of its games every time something is done to it; if that fails, the
+// game plays on, and what was done to it returns a SaveError.
+type Store interface {
+	Save(dg *DiceGame) error
+	Load(ID string, archived bool) (*DiceGame, error)
+	List(archived bool) ([]string, error)
+	Archive(ID string) error
+}
+
+// setStore - save the game to st from now on (nil: stop saving it)
+func (dg *DiceGame) setStore(st Store) {
+	dg.mu.Lock()
+	defer dg.mu.Unlock()
+	dg.store, dg.saveErr = st, nil
+}
+
+// SaveError - the game played on, but couldn't be saved
+type SaveError struct {
+	ID  string
+	Err error
+}
+
+func (e *SaveError) Error() string {
+	return fmt.Sprintf("game %s played on, but couldn't be saved: %v", e.ID, e.Err)
+}
+
+func (e *SaveError) Unwrap() error {
+	return e.Err
+}
+
+// IsSaveError - is err a SaveError?
+func IsSaveError(err error) bool {
+	var se *SaveError
+	return errors.As(err, &se)
+}
+
+// FileStore - a directory with a JSON file for each game, and the archived
+// games in a subdirectory
+type FileStore struct {
+	Dir string
+}
+
+const archiveDir = "archive"
+
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(filepath.Join(dir, archiveDir), 0o755); err != nil {
+		return nil, err
+	}
+	return &FileStore{Dir: dir}, nil
+}
+
+func (fs *FileStore) path(ID string, archived bool) (string, error) {
+	if ID == "" || ID == "." || ID == ".." || strings.ContainsAny(ID, `/\`) {
+		return "", fmt.Errorf("game ID %q can't be a file name", ID)
+	}
+	if archived {
+		return filepath.Join(fs.Dir, archiveDir, ID+".json"), nil
+	}
+	return filepath.Join(fs.Dir, ID+".json"), nil
+}
+
+// Save - write the game out; a new file is written and moved into place, so
+// a crash mid-write leaves the last save alone
+func (fs *FileStore) Save(dg *DiceGame) error {
+	path, err := fs.path(dg.ID, false)
+	if err != nil {
+		return err
+	}
+	buf, err := json.MarshalIndent(dg, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(fs.Dir, "."+dg.ID+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(buf); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (fs *FileStore) Load(ID string, archived bool) (*DiceGame, error) {
+	path, err := fs.path(ID, archived)
+	if err != nil {
+		return nil, err
+	}
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var dg DiceGame
+	if err := json.Unmarshal(buf, &dg); err != nil {
+		return nil, fmt.Errorf("game file %s: %v", path, err)
+	}
+	return &dg, nil
+}
+
+// List - IDs of the games stored, sorted
+func (fs *FileStore) List(archived bool) ([]string, error) {
+	dir := fs.Dir
+	if archived {
+		dir = filepath.Join(fs.Dir, archiveDir)
+	}
+	ents, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	ids := []string{}
+	for _, ent := range ents {
+		name := ent.Name()
+		if ent.IsDir() || strings.HasPrefix(name, ".") || filepath.Ext(name) != ".json" {
+			continue
+		}
+		ids = append(ids, strings.TrimSuffix(name, ".json"))
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// Archive - move a game's file in with the archived games
+func (fs *FileStore) Archive(ID string) error {
+	from, err := fs.path(ID, false)
+	if err != nil {
+		return err
+	}
+	to, _ := fs.path(ID, true)
+	return os.Rename(from, to)
+}
@@ -0,0 +1,205 @@
+package dicegame
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"wojones.com/src/diceturn"
+)
+
+func TestFileStore(t *testing.T) {
+	dir := t.TempDir()
+	st, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+	reg, err := OpenRegistry(st)
+	if err != nil {
+		t.Fatalf("OpenRegistry: %v", err)
+	}
+	dg, err := reg.Create("G1", "Alpha", "Beta", "Gamma")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	dg.SetDiceSource(NewSeededSource(5))
+	if e := dg.RollDice(diceturn.AllDice); e != nil {
+		t.Fatalf("Roll failed: %v", e)
+	}
+	if _, e := dg.PassDice("Beta"); e != nil {
+		t.Fatalf("Pass failed: %v", e)
+	}
+	if _, err := reg.Create("G2", "Alpha", "Beta", "Gamma"); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := reg.Archive("G2"); err != nil {
+		t.Fatalf("Archive: %v", err)
+	}
+	if _, err := reg.Create("../G3", "Alpha", "Beta", "Gamma"); err == nil {
+		t.Errorf("Created a game that would be saved outside the store")
+	}
+
+	// Restart: everything saved as it was played
+	reg2, err := OpenRegistry(st)
+	if err != nil {
+		t.Fatalf("OpenRegistry: %v", err)
+	}
+	if ids := reg2.List(); len(ids) != 1 || ids[0] != "G1" {
+		t.Errorf("Active games after restart: %v", ids)
+	}
+	if ids := reg2.ListArchived(); len(ids) != 1 || ids[0] != "G2" {
+		t.Errorf("Archived games after restart: %v", ids)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "archive", "G2.json")); err != nil {
+		t.Errorf("Archived game not moved: %v", err)
+	}
+	back, _ := reg2.Lookup("G1")
+	want, _ := json.Marshal(dg)
+	got, _ := json.Marshal(back)
+	if string(got) != string(want) {
+		t.Errorf("Loaded game differs:\n%s\nexpected:\n%s", got, want)
+	}
+	if back.CurPlayer() != "Beta" || back.PrevPlayer() != "Alpha" || back.PrevTurn().Player != "Alpha" {
+		t.Errorf("Loaded game isn't Beta rolling against Alpha: %s", back.CurTurn())
+	}
+
+	// Both carry on with the same dice, and the loaded game keeps saving
+	for _, g := range []*DiceGame{dg, back} {
+		if e := g.RollDice(diceturn.AllDice); e != nil {
+			t.Fatalf("Roll after restart: %v", e)
+		}
+	}
+	if a, b := dg.CurrentTurn().Rolls[0], back.CurrentTurn().Rolls[0]; a.RollResults != b.RollResults {
+		t.Errorf("Loaded game rolled %v, original %v", b.RollResults, a.RollResults)
+	}
+	saved, err := st.Load("G1", false)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if saved.CurrentTurn().NumRolls != 1 {
+		t.Errorf("Roll after restart not saved: %s", saved.CurTurn())
+	}
+}
+
+// playsOn - a file store where someone plays the game just after it's
+// archived, as a request that looked it up first might
+type playsOn struct {
+	*FileStore
+	dg *DiceGame
+}
+
+func (po playsOn) Archive(ID string) error {
+	err := po.FileStore.Archive(ID)
+	po.dg.RollDice(diceturn.AllDice)
+	return err
+}
+
+// archiveFails - a store that can't archive, counting the saves
+type archiveFails struct {
+	saves int
+}
+
+func (af *archiveFails) Save(dg *DiceGame) error                          { af.saves++; return nil }
+func (af *archiveFails) Load(ID string, archived bool) (*DiceGame, error) { return nil, os.ErrNotExist }
+func (af *archiveFails) List(archived bool) ([]string, error)             { return nil, nil }
+func (af *archiveFails) Archive(ID string) error                          { return os.ErrPermission }
+
+func TestArchiveStopsSaving(t *testing.T) {
+	dir := t.TempDir()
+	fs, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+	st := &playsOn{FileStore: fs}
+	reg, err := OpenRegistry(st)
+	if err != nil {
+		t.Fatalf("OpenRegistry: %v", err)
+	}
+	if st.dg, err = reg.Create("G1", "Alpha", "Beta"); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := reg.Archive("G1"); err != nil {
+		t.Fatalf("Archive: %v", err)
+	}
+	if st.dg.CurrentTurn().NumRolls != 1 {
+		t.Fatalf("Nobody played on: %s", st.dg.CurTurn())
+	}
+	if _, err := os.Stat(filepath.Join(dir, "G1.json")); !os.IsNotExist(err) {
+		t.Errorf("Archived game saved among the active ones: %v", err)
+	}
+
+	// If it can't be archived, it's still active and still saved
+	af := &archiveFails{}
+	reg2, err := OpenRegistry(af)
+	if err != nil {
+		t.Fatalf("OpenRegistry: %v", err)
+	}
+	dg2, err := reg2.Create("G2", "Alpha", "Beta")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := reg2.Archive("G2"); err == nil {
+		t.Fatalf("Archive didn't fail")
+	}
+	if _, ok := reg2.Lookup("G2"); !ok {
+		t.Errorf("Game that couldn't be archived isn't active")
+	}
+	saves := af.saves
+	if e := dg2.RollDice(diceturn.AllDice); e != nil {
+		t.Fatalf("Roll failed: %v", e)
+	}
+	if af.saves != saves+1 {
+		t.Errorf("Roll after a failed archive not saved")
+	}
+}
+
+// flaky - a store that can't save while it's down
+type flaky struct {
+	archiveFails
+	down bool
+}
+
+func (f *flaky) Save(dg *DiceGame) error {
+	if f.down {
+		return os.ErrPermission
+	}
+	return nil
+}
+
+func TestSaveError(t *testing.T) {
+	st := &flaky{}
+	reg, err := OpenRegistry(st)
+	if err != nil {
+		t.Fatalf("OpenRegistry: %v", err)
+	}
+	dg, err := reg.Create("G1", "Alpha", "Beta")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	// The game plays on, and says it isn't saved until it is again
+	st.down = true
+	if err := dg.RollWith(1, 2, 4); !IsSaveError(err) || !errors.Is(err, os.ErrPermission) {
+		t.Errorf("Roll that couldn't be saved: %v", err)
+	}
+	if dg.CurrentTurn().NumRolls != 1 {
+		t.Errorf("Roll that couldn't be saved wasn't made: %s", dg.CurTurn())
+	}
+	if _, err := dg.PassDice("Alpha"); err == nil || IsSaveError(err) {
+		t.Errorf("Passing to yourself: %v", err)
+	}
+	if err := dg.SetBot("Beta", BotGreedyLow); !IsSaveError(err) {
+		t.Errorf("Bot that couldn't be saved: %v", err)
+	}
+	st.down = false
+	if _, err := dg.PassDice("Beta"); err != nil {
+		t.Errorf("Pass once saving again: %v", err)
+	}
+
+	st.down = true
+	if _, err := reg.Create("G2", "Alpha", "Beta"); !IsSaveError(err) {
+		t.Errorf("Create that couldn't be saved: %v", err)
+	}
+}
@@ -0,0 +1,136 @@
+package dicegame
+
+import (
+	"fmt"
+)
+
+// Undo and redo work off the log: undoing an action replays the game without
+// it, and the undo itself is logged as a correction. Only rolls (and the keeps
+// that go with them), passes and stand-ins in the current round can be undone.
+
+// undoable - split a log into the actions in effect, and the ones undone that
+// can still be redone (last undone last). Doing anything else after an undo
+// means there's nothing left to redo.
+func undoable(log []Action) (done []Action, undone []Action) {
+	for _, a := range log {
+		switch a.Kind {
+		case ActUndo:
+			if len(done) > 0 {
+				undone = append(undone, done[len(done)-1])
+				done = done[:len(done)-1]
+			}
+		case ActRedo:
+			if len(undone) > 0 {
+				done = append(done, undone[len(undone)-1])
+				undone = undone[:len(undone)-1]
+			}
+		default:
+			done = append(done, a)
+			undone = nil
+		}
+	}
+	return done, undone
+}
+
+// Undo - take back the last roll, pass or stand-in. Whatever bots did since
+// is taken back too, as far as the last thing a person did.
+func (dg *DiceGame) Undo() error {
+	dg.mu.Lock()
+	defer dg.mu.Unlock()
+	if err := dg.undo(); err != nil {
+		return err
+	}
+	for dg.IsBot(dg.CurPlayer()) && !dg.Over {
+		if dg.undo() != nil {
+			break
+		}
+	}
+	return dg.saved(nil)
+}
+
+func (dg *DiceGame) undo() error {
+	done, _ := undoable(dg.Log)
+	if len(done) == 0 {
+		return fmt.Errorf("nothing to undo")
+	}
+	last := done[len(done)-1]
+	if last.Kind == ActSnapshot {
+		return fmt.Errorf("can't undo past %v, where the game's log starts", last)
+	}
+	if last.Kind != ActRoll && last.Kind != ActPass && last.Kind != ActStandIn {
+		return fmt.Errorf("nothing to undo in round %d (last was %v)", dg.Round, last)
+	}
+	if err := dg.rebuild(done[:len(done)-1]); err != nil {
+		return err
+	}
+	dg.record(Action{Kind: ActUndo, Ref: last.Seq})
+	dg.publish(Event{Kind: EventUndo, Player: dg.CurrentTurn().Player,
+		Text: fmt.Sprintf("Undid %v", last)})
+	return nil
+}
+
+// Redo - put back the last thing undone, and what the bots did after it
+func (dg *DiceGame) Redo() error {
+	dg.mu.Lock()
+	defer dg.mu.Unlock()
+	if err := dg.redo(); err != nil {
+		return err
+	}
+	for dg.IsBot(dg.CurPlayer()) && !dg.Over {
+		if dg.redo() != nil {
+			break
+		}
+	}
+	return dg.saved(dg.playBots())
+}
+
+func (dg *DiceGame) redo() error {
+	_, undone := undoable(dg.Log)
+	if len(undone) == 0 {
+		return fmt.Errorf("nothing to redo")
+	}
+	a := undone[len(undone)-1]
+	logged := dg.Log
+	if err := dg.apply(a); err != nil {
+		return err
+	}
+	dg.Log = logged
+	dg.record(Action{Kind: ActRedo, Ref: a.Seq})
+	dg.publish(Event{Kind: EventRedo, Player: dg.CurrentTurn().Player,
+		Text: fmt.Sprintf("Redid %v", a)})
+	return nil
+}
+
+// Corrections - the undos and redos in the game's log
+func (dg *DiceGame) Corrections() []Action {
+	dg.mu.Lock()
+	defer dg.mu.Unlock()
+	var cs []Action
+	for _, a := range dg.Log {
+		if a.Kind == ActUndo || a.Kind == ActRedo {
+			cs = append(cs, a)
+		}
+	}
+	return cs
+}
+
+// rebuild - reset the game to what the actions make of it, keeping its log,
+// its subscribers, where it's stored and its lock (which others may be
+// waiting on, so the game isn't simply overwritten)
+func (dg *DiceGame) rebuild(actions []Action) error {
+	g, err := Replay(actions)
+	if err != nil {
+		return err
+	}
+	dg.restore(g)
+	return nil
+}
+
+// restore - take on g's state of play, keeping everything else
+func (dg *DiceGame) restore(g *DiceGame) {
+	dg.Players, dg.Scores, dg.Bots, dg.Turns = g.Players, g.Scores, g.Bots, g.Turns
+	dg.Cur, dg.Prev, dg.PrevTurnNo, dg.Direction = g.Cur, g.Prev, g.PrevTurnNo, g.Direction
+	dg.Rules, dg.SourceKind, dg.Seed, dg.Draws = g.Rules, g.SourceKind, g.Seed, g.Draws
+	dg.Round, dg.Over, dg.Loser = g.Round, g.Over, g.Loser
+	dg.source = g.source
+}
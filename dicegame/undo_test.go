@@ -0,0 +1,151 @@
+package dicegame
+
+import (
+	"encoding/json"
+	"testing"
+
+	"wojones.com/src/diceturn"
+)
+
+func TestUndoRedo(t *testing.T) {
+	dg := newGame(t, "G1", "Alpha", "Beta", "Gamma")
+	if e := dg.Undo(); e == nil {
+		t.Errorf("Undid with nothing done")
+	}
+	if e := dg.RollWith(3, 1, 2); e != nil {
+		t.Fatalf("Roll failed: %v", e)
+	}
+	if _, e := dg.PassDice("Beta"); e != nil {
+		t.Fatalf("Pass failed: %v", e)
+	}
+	before, _ := json.Marshal(dg.Scores)
+	// Fat-fingered: meant 1 2 4
+	if e := dg.RollWith(1, 2, 3); e != nil {
+		t.Fatalf("Roll failed: %v", e)
+	}
+	if c := dg.Scores["Alpha"].Chevrons[0].Count; c != 2 {
+		t.Fatalf("Alpha has %d marks, expected 2", c)
+	}
+
+	if e := dg.Undo(); e != nil {
+		t.Fatalf("Undo failed: %v", e)
+	}
+	if c := dg.Scores["Alpha"].Chevrons[0].Count; c != 0 {
+		t.Errorf("Undo left Alpha with %d marks", c)
+	}
+	if n := len(dg.CurrentTurn().Rolls); n != 0 {
+		t.Errorf("Undo left Beta with %d rolls", n)
+	}
+
+	// Back over the pass: Alpha rolling again, nothing to settle against
+	if e := dg.Undo(); e != nil {
+		t.Fatalf("Undo failed: %v", e)
+	}
+	if dg.CurrentTurn().Player != "Alpha" || dg.PrevTurn() != nil || len(dg.Turns) != 1 {
+		t.Errorf("Pass not undone: %s", dg.GameStatus())
+	}
+
+	if e := dg.Redo(); e != nil {
+		t.Fatalf("Redo failed: %v", e)
+	}
+	if dg.CurPlayer() != "Beta" || dg.PrevPlayer() != "Alpha" || dg.PrevTurn().Player != "Alpha" {
+		t.Errorf("Pass not redone: %s", dg.GameStatus())
+	}
+	if after, _ := json.Marshal(dg.Scores); string(after) != string(before) {
+		t.Errorf("Redo gave scores %s, expected %s", after, before)
+	}
+
+	// Rolling again drops the undone roll
+	if e := dg.RollWith(1, 2, 4); e != nil {
+		t.Fatalf("Roll failed: %v", e)
+	}
+	if e := dg.Redo(); e == nil {
+		t.Errorf("Redid after a new roll")
+	}
+	if cs := dg.Corrections(); len(cs) != 3 {
+		t.Errorf("Expected three corrections, got %v", cs)
+	}
+
+	// The log, corrections and all, replays to the same game
+	back, err := Replay(dg.Log)
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	w, _ := json.Marshal(dg)
+	g, _ := json.Marshal(back)
+	if string(g) != string(w) {
+		t.Errorf("Replayed game differs:\n%s\nexpected:\n%s", g, w)
+	}
+}
+
+func TestUndoRound(t *testing.T) {
+	dg := newGame(t, "G1", "Alpha", "Beta", "Gamma")
+	rules := diceturn.DefaultRules()
+	rules.ChevronSize = 2
+	if e := dg.SetRules(rules); e != nil {
+		t.Fatalf("SetRules: %v", e)
+	}
+	if e := dg.RollWith(3, 1, 2); e != nil {
+		t.Fatalf("Roll failed: %v", e)
+	}
+	if !dg.Over {
+		t.Fatalf("Consecutives should have ended the round")
+	}
+
+	// The roll that ended the round can be taken back...
+	if e := dg.Undo(); e != nil {
+		t.Fatalf("Undo failed: %v", e)
+	}
+	if dg.Over || dg.Loser != "" || dg.Scores["Beta"].Chevrons[0].Count != 0 {
+		t.Errorf("Round still over after undo: %s", dg.GameStatus())
+	}
+	if e := dg.Redo(); e != nil || !dg.Over {
+		t.Fatalf("Redo failed: %v", e)
+	}
+
+	// ...but not once the next round has started
+	if e := dg.NewRound(); e != nil {
+		t.Fatalf("NewRound: %v", e)
+	}
+	if e := dg.Undo(); e == nil {
+		t.Errorf("Undid into the last round")
+	}
+}
+
+func TestUndoStandIn(t *testing.T) {
+	dg := newGame(t, "G1", "Alpha", "Beta", "Gamma")
+	if e := dg.RollWith(3, 1, 2); e != nil {
+		t.Fatalf("Roll failed: %v", e)
+	}
+	// Meant to pass to Gamma, and Beta's already got someone rolling for them
+	if _, e := dg.PassDice("Beta"); e != nil {
+		t.Fatalf("Pass failed: %v", e)
+	}
+	if e := dg.StandIn("Zed"); e != nil {
+		t.Fatalf("StandIn: %v", e)
+	}
+
+	if e := dg.Undo(); e != nil {
+		t.Fatalf("Undoing the stand-in: %v", e)
+	}
+	if tp := dg.CurrentTurn(); tp.Player != "Beta" || tp.RolledBy != "" {
+		t.Errorf("Undo left %s", tp.Who())
+	}
+	if e := dg.Undo(); e != nil {
+		t.Fatalf("Undoing the pass after a stand-in: %v", e)
+	}
+	if p := dg.CurPlayer(); p != "Alpha" || dg.CurrentTurn().NumRolls != 1 {
+		t.Errorf("Undoing the pass left %s with the dice", p)
+	}
+	if _, e := dg.PassDice("Gamma"); e != nil {
+		t.Fatalf("Pass failed: %v", e)
+	}
+
+	rg, err := Replay(dg.Log)
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if rg.CurPlayer() != "Gamma" || len(rg.Turns) != 2 || rg.Turns[1].RolledBy != "" {
+		t.Errorf("Replay differs: %s", rg.CurTurn())
+	}
+}
@@ -0,0 +1,173 @@
+package diceodds
+
+import (
+	"fmt"
+	"sort"
+
+	"wojones.com/src/diceturn"
+)
+
+// The exact odds of a turn: for each move the roller could make next, the
+// chance of beating the turn passed to them, of tying it, and of rolling
+// consecutives before the turn's done. Every roll the dice could come up is
+// played through diceturn, so only moves the house rules allow are counted,
+// and every later move is assumed to be the best one. Dice never leave the
+// table here.
+
+// Choice - a move and how it plays out, if the best moves follow
+type Choice struct {
+	ToRoll  int     `json:"toroll"`  // dice to roll, 0 to stand
+	Beat    float64 `json:"beat"`    // chance of beating the turn to beat
+	Tie     float64 `json:"tie"`     // chance of tying it (ties go against the roller)
+	Consecs float64 `json:"consecs"` // chance of consecutives on a roll still to come
+}
+
+func (c Choice) String() string {
+	move := "stand"
+	if c.ToRoll != 0 {
+		move = fmt.Sprintf("roll 0b%03b", c.ToRoll)
+	}
+	return fmt.Sprintf("%s: beat %.1f%%, tie %.1f%%, consecutives %.1f%%",
+		move, 100*c.Beat, 100*c.Tie, 100*c.Consecs)
+}
+
+// better - is c a better move than o? The more likely to beat the better;
+// then the more likely to tie, since a tie costs less than losing outright.
+func (c Choice) better(o Choice) bool {
+	if c.Beat != o.Beat {
+		return c.Beat > o.Beat
+	}
+	if c.Tie != o.Tie {
+		return c.Tie > o.Tie
+	}
+	return c.ToRoll < o.ToRoll
+}
+
+// Target - the value a turn has to beat: prev's, or the opening value if
+// prev is nil
+func Target(rules diceturn.Rules, prev *diceturn.DiceTurn) (int, diceturn.RollValueSpecial) {
+	if prev == nil {
+		return rules.OpeningValue, diceturn.NothingSpecial
+	}
+	return prev.Score, prev.ScoreSpecial
+}
+
+// Odds - every move the roller of dt may make next, best first, against
+// prev (nil for the first turn of a round, against the opening value)
+func Odds(rules diceturn.Rules, dt diceturn.DiceTurn, prev *diceturn.DiceTurn) ([]Choice, error) {
+	if err := rules.Validate(); err != nil {
+		return nil, err
+	}
+	if prev != nil && prev.NumRolls == 0 {
+		return nil, fmt.Errorf("%s hasn't rolled yet", prev.Player)
+	}
+	score, special := Target(rules, prev)
+	s := solver{rules: rules, against: diceturn.Rank(score, special), seen: map[state][]Choice{}}
+	choices := s.choices(dt)
+	if len(choices) == 0 {
+		return nil, fmt.Errorf("%s can't roll or stand", dt.Player)
+	}
+	return choices, nil
+}
+
+type solver struct {
+	rules   diceturn.Rules
+	against int
+	seen    map[state][]Choice
+}
+
+// state - all that matters about a turn for what's allowed next: what each
+// roll rolled and how it came up (what was kept follows from the next roll)
+type state struct {
+	numRolls int
+	rolled   [3]int
+	results  [3][3]int
+}
+
+func stateOf(dt diceturn.DiceTurn) state {
+	st := state{numRolls: dt.NumRolls}
+	for i := 0; i < dt.NumRolls; i++ {
+		st.rolled[i] = dt.Rolls[i].Rolled
+		st.results[i] = dt.Rolls[i].RollResults
+	}
+	return st
+}
+
+// choices - the moves open at dt, best first
+func (s solver) choices(dt diceturn.DiceTurn) []Choice {
+	st := stateOf(dt)
+	if cs, ok := s.seen[st]; ok {
+		return cs
+	}
+	var cs []Choice
+	if dt.NumRolls > 0 {
+		cs = append(cs, s.stand(dt))
+	}
+	for toroll := 1; toroll <= diceturn.AllDice; toroll++ {
+		if dt.RollCheckWith(s.rules, toroll) == nil {
+			cs = append(cs, s.roll(dt, toroll))
+		}
+	}
+	sort.Slice(cs, func(i, j int) bool { return cs[i].better(cs[j]) })
+	s.seen[st] = cs
+	return cs
+}
+
+// stand - the turn ends on its last roll
+func (s solver) stand(dt diceturn.DiceTurn) Choice {
+	c := Choice{}
+	mine := diceturn.Rank(dt.Rolls[dt.NumRolls-1].TurnValueWith(s.rules))
+	switch {
+	case mine < s.against:
+		c.Beat = 1
+	case mine == s.against:
+		c.Tie = 1
+	}
+	return c
+}
+
+// roll - every way the dice in toroll could come up, each followed by the
+// best move from there
+func (s solver) roll(dt diceturn.DiceTurn, toroll int) Choice {
+	var dice []int
+	for d := 0; d < 3; d++ {
+		if toroll&(diceturn.Die0<<d) != 0 {
+			dice = append(dice, d)
+		}
+	}
+	ways := 1
+	for range dice {
+		ways *= 6
+	}
+
+	c := Choice{ToRoll: toroll}
+	for w := 0; w < ways; w++ {
+		var vals [3]int
+		for i, n := 0, w; i < len(dice); i, n = i+1, n/6 {
+			vals[dice[i]] = n%6 + 1
+		}
+		next := dt
+		next.Rolls = append([]diceturn.DiceRoll(nil), dt.Rolls[:dt.NumRolls]...)
+		if err := next.TurnRollWith(s.rules, toroll, vals[0], vals[1], vals[2]); err != nil {
+			// RollCheckWith allowed it, so this can't happen
+			panic(fmt.Sprintf("diceodds: rolling 0b%03b %v: %v", toroll, vals, err))
+		}
+
+		var best Choice
+		if next.NumRolls == 3 {
+			best = s.stand(next)
+		} else {
+			best = s.choices(next)[0]
+		}
+		if next.Rolls[next.NumRolls-1].Consecs {
+			best.Consecs = 1
+		}
+		c.Beat += best.Beat
+		c.Tie += best.Tie
+		c.Consecs += best.Consecs
+	}
+	c.Beat /= float64(ways)
+	c.Tie /= float64(ways)
+	c.Consecs /= float64(ways)
+	return c
+}
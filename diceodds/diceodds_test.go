@@ -0,0 +1,75 @@
+package diceodds
+
+import (
+	"math"
+	"testing"
+
+	"wojones.com/src/diceturn"
+)
+
+// turn - a turn rolled the given way; each roll is the dice rolled and all
+// three values
+func turn(t *testing.T, rolls ...[4]int) diceturn.DiceTurn {
+	dt := diceturn.NewTurn("Me")
+	for _, r := range rolls {
+		if err := dt.TurnRoll(r[0], r[1], r[2], r[3]); err != nil {
+			t.Fatalf("Roll %v: %v", r, err)
+		}
+	}
+	return dt
+}
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestOddsThirdRoll(t *testing.T) {
+	rules := diceturn.DefaultRules()
+	dt := turn(t, [4]int{diceturn.AllDice, 2, 4, 5}, [4]int{diceturn.Die1 | diceturn.Die2, 2, 3, 5})
+	prev := turn(t, [4]int{diceturn.AllDice, 2, 2, 4})
+	prev.CloseTurn()
+
+	cs, err := Odds(rules, dt, &prev)
+	if err != nil {
+		t.Fatalf("Odds: %v", err)
+	}
+	// Standing, either single die from the second roll, or picking up the 2
+	// and the 3 to go for triple-fives
+	if len(cs) != 4 {
+		t.Fatalf("Expected four moves, got %v", cs)
+	}
+	// Keeping the 2 and 3 against an 8: a 6, 1 or 2 beats it, a 3 ties, and
+	// a 1 or 4 makes consecutives
+	best := cs[0]
+	if best.ToRoll != diceturn.Die2 || !near(best.Beat, 3.0/6) || !near(best.Tie, 1.0/6) || !near(best.Consecs, 2.0/6) {
+		t.Errorf("Best move %v, expected rolling the 5", best)
+	}
+	// Standing on 10 loses
+	if last := cs[len(cs)-1]; last.ToRoll != 0 || last.Beat != 0 || last.Tie != 0 {
+		t.Errorf("Worst move %v, expected standing", last)
+	}
+}
+
+func TestOddsFirstRoll(t *testing.T) {
+	rules := diceturn.DefaultRules()
+	cs, err := Odds(rules, diceturn.NewTurn("Me"), nil)
+	if err != nil {
+		t.Fatalf("Odds: %v", err)
+	}
+	if len(cs) != 1 || cs[0].ToRoll != diceturn.AllDice {
+		t.Fatalf("The first roll has to be all the dice, not %v", cs)
+	}
+	// Nearly everything beats the opening 14
+	if c := cs[0]; c.Beat < 0.99 || c.Beat+c.Tie > 1+1e-9 {
+		t.Errorf("Against the opening: %v", c)
+	}
+
+	done := turn(t, [4]int{diceturn.AllDice, 5, 5, 4}, [4]int{diceturn.Die2, 0, 0, 3}, [4]int{diceturn.Die2, 0, 0, 5})
+	done.CloseTurn()
+	if cs, err := Odds(rules, done, &done); err != nil || len(cs) != 1 || cs[0].ToRoll != 0 || cs[0].Tie != 1 {
+		t.Errorf("After the last roll, standing on a tie: %v (%v)", cs, err)
+	}
+	if _, err := Odds(rules, done, &diceturn.DiceTurn{Player: "Nobody"}); err == nil {
+		t.Errorf("Odds against a turn that never rolled")
+	}
+}
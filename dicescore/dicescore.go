@@ -2,6 +2,9 @@ package dicescore
 
 import "fmt"
 
+// DefaultChevronSize - marks to fill a chevron: four groups of five
+const DefaultChevronSize = 20
+
 type Chevron struct {
 	Count  int32 `json:"count"`
 	Filled bool  `json:"is_filled"`
@@ -18,10 +21,118 @@ func NewChevron() Chevron {
 }
 
 func (c Chevron) String() string {
-	return fmt.Sprintf("C: %d", c.Count)
+	s := fmt.Sprintf("C: %d", c.Count)
+	if c.Paid {
+		s += " (paid)"
+	} else if c.Filled {
+		s += " (filled)"
+	}
+	return s
 }
 
 func NewPlayerScore(player string) PlayerScore {
 	return PlayerScore{Player: player,
 		Chevrons: []Chevron{{Count: 0, Filled: false, Paid: false}}}
 }
+
+// Current - the chevron marks go on: the last one. Nil if there are none.
+func (ps *PlayerScore) Current() *Chevron {
+	if len(ps.Chevrons) == 0 {
+		return nil
+	}
+	return &ps.Chevrons[len(ps.Chevrons)-1]
+}
+
+// AddMarks - tally marks against the player on their current chevron. A
+// chevron is filled at size marks; anything past that is lost, and no more
+// marks go on it until the next chevron is opened. Returns whether these
+// marks filled the chevron.
+func (ps *PlayerScore) AddMarks(marks int, size int) (bool, error) {
+	if marks < 0 {
+		return false, fmt.Errorf("cannot take away marks (%d)", marks)
+	}
+	if size <= 0 {
+		return false, fmt.Errorf("invalid chevron size %d", size)
+	}
+	if len(ps.Chevrons) == 0 {
+		ps.Chevrons = append(ps.Chevrons, NewChevron())
+	}
+	cp := ps.Current()
+	if cp.Filled {
+		return false, fmt.Errorf("%s's chevron %d is already filled", ps.Player, len(ps.Chevrons))
+	}
+	if cp.Count+int32(marks) >= int32(size) {
+		cp.Count = int32(size)
+		cp.Filled = true
+		return true, nil
+	}
+	cp.Count += int32(marks)
+	return false, nil
+}
+
+// OpenChevron - start the player's next chevron, once the current is filled
+func (ps *PlayerScore) OpenChevron() error {
+	if cp := ps.Current(); cp != nil && !cp.Filled {
+		return fmt.Errorf("%s's chevron %d isn't filled yet", ps.Player, len(ps.Chevrons))
+	}
+	ps.Chevrons = append(ps.Chevrons, NewChevron())
+	return nil
+}
+
+// PayChevron - the player paid up for a filled chevron (numbered from 0)
+func (ps *PlayerScore) PayChevron(idx int) error {
+	if idx < 0 || idx >= len(ps.Chevrons) {
+		return fmt.Errorf("%s has no chevron %d", ps.Player, idx)
+	}
+	cp := &ps.Chevrons[idx]
+	if !cp.Filled {
+		return fmt.Errorf("%s's chevron %d isn't filled; nothing to pay", ps.Player, idx)
+	}
+	if cp.Paid {
+		return fmt.Errorf("%s already paid for chevron %d", ps.Player, idx)
+	}
+	cp.Paid = true
+	return nil
+}
+
+// Filled - how many chevrons the player has filled
+func (ps PlayerScore) Filled() int {
+	n := 0
+	for _, c := range ps.Chevrons {
+		if c.Filled {
+			n++
+		}
+	}
+	return n
+}
+
+// Unpaid - indexes of filled chevrons the player still owes for
+func (ps PlayerScore) Unpaid() []int {
+	idxs := []int{}
+	for i, c := range ps.Chevrons {
+		if c.Filled && !c.Paid {
+			idxs = append(idxs, i)
+		}
+	}
+	return idxs
+}
+
+// Check - make sure the scorecard makes sense: counts within the chevron
+// size, only filled chevrons paid, and only the last chevron open
+func (ps PlayerScore) Check(size int) error {
+	for i, c := range ps.Chevrons {
+		if c.Count < 0 || c.Count > int32(size) {
+			return fmt.Errorf("%s's chevron %d has %d marks (of %d)", ps.Player, i, c.Count, size)
+		}
+		if c.Filled != (c.Count == int32(size)) {
+			return fmt.Errorf("%s's chevron %d filled is %v with %d marks", ps.Player, i, c.Filled, c.Count)
+		}
+		if c.Paid && !c.Filled {
+			return fmt.Errorf("%s paid for unfilled chevron %d", ps.Player, i)
+		}
+		if !c.Filled && i != len(ps.Chevrons)-1 {
+			return fmt.Errorf("%s's chevron %d is open, but isn't the last", ps.Player, i)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package dicescore
+
+import "testing"
+
+func TestChevronLifecycle(t *testing.T) {
+	ps := NewPlayerScore("Danny")
+
+	if filled, err := ps.AddMarks(12, DefaultChevronSize); err != nil || filled {
+		t.Fatalf("12 marks: filled %v, err %v", filled, err)
+	}
+	if err := ps.OpenChevron(); err == nil {
+		t.Errorf("Opened a chevron with the current one unfilled")
+	}
+	if err := ps.PayChevron(0); err == nil {
+		t.Errorf("Paid for an unfilled chevron")
+	}
+	if _, err := ps.AddMarks(-1, DefaultChevronSize); err == nil {
+		t.Errorf("Took away marks")
+	}
+
+	if filled, err := ps.AddMarks(10, DefaultChevronSize); err != nil || !filled {
+		t.Fatalf("22 marks: filled %v, err %v", filled, err)
+	}
+	if c := ps.Chevrons[0].Count; c != DefaultChevronSize {
+		t.Errorf("Filled chevron has %d marks, expected %d", c, DefaultChevronSize)
+	}
+	if _, err := ps.AddMarks(1, DefaultChevronSize); err == nil {
+		t.Errorf("Marked a filled chevron")
+	}
+	if u := ps.Unpaid(); len(u) != 1 || u[0] != 0 {
+		t.Errorf("Unpaid chevrons %v, expected [0]", u)
+	}
+
+	if err := ps.OpenChevron(); err != nil {
+		t.Fatalf("Couldn't open the next chevron: %v", err)
+	}
+	if filled, err := ps.AddMarks(3, DefaultChevronSize); err != nil || filled {
+		t.Errorf("3 marks on new chevron: filled %v, err %v", filled, err)
+	}
+	if err := ps.PayChevron(0); err != nil {
+		t.Errorf("Couldn't pay for filled chevron: %v", err)
+	}
+	if err := ps.PayChevron(0); err == nil {
+		t.Errorf("Paid twice for the same chevron")
+	}
+	if err := ps.PayChevron(5); err == nil {
+		t.Errorf("Paid for a chevron that doesn't exist")
+	}
+	if ps.Filled() != 1 || len(ps.Unpaid()) != 0 {
+		t.Errorf("Filled %d, unpaid %v; expected 1 and none", ps.Filled(), ps.Unpaid())
+	}
+	if err := ps.Check(DefaultChevronSize); err != nil {
+		t.Errorf("Scorecard doesn't check out: %v", err)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	bad := []PlayerScore{
+		{Player: "over", Chevrons: []Chevron{{Count: 21}}},
+		{Player: "unfilled", Chevrons: []Chevron{{Count: 20}}},
+		{Player: "paid", Chevrons: []Chevron{{Count: 3, Paid: true}}},
+		{Player: "open", Chevrons: []Chevron{{Count: 3}, {Count: 0}}},
+	}
+	for _, ps := range bad {
+		if err := ps.Check(DefaultChevronSize); err == nil {
+			t.Errorf("Bogus scorecard %v passed", ps)
+		}
+	}
+}
@@ -40,14 +40,38 @@ const (
 //			 or just those kept from those rolled in roll 2. If the latter, then Kept
 //			 will aways be a subset of Rolled.
 type DiceRoll struct {
-	Rolled      int    // bitmap: xxxxx111 = all, xxxxx001 is color die, etc.
-	RollResults [3]int // New values are those indicated by Rolled; if bit not set, then value comes from prior roll
-	OffTable    int    // bitmap: dice that left the table
-	Kept        int    // bitmap: dice kept after the roll
-	Consecs     bool   // Calculated from RollResults array ()
+	Rolled      int       // bitmap: xxxxx111 = all, xxxxx001 is die 0, etc.
+	RollResults [3]int    // New values are those indicated by Rolled; if bit not set, then value comes from prior roll
+	OffTable    int       // bitmap: dice that left the table
+	Kept        int       // bitmap: dice kept after the roll
+	Consecs     bool      // Calculated from RollResults array ()
+	Marks       []Marking `json:",omitempty"` // Scored as soon as the dice stopped
 }
 
-// NOTE TO SELF: Need to track the colored die; I guess it can always be die0.
+// Marking - marks a player took during a turn, rather than when it was settled
+type Marking struct {
+	Player string `json:"player"`
+	Marks  int    `json:"marks"`
+	Reason string `json:"reason"`
+}
+
+// Reasons for a Marking
+const (
+	MarkConsecs  = "consecutives"
+	MarkOffTable = "off the table"
+	MarkSettled  = "settlement"
+)
+
+func (m Marking) String() string {
+	return fmt.Sprintf("%s takes %d (%s)", m.Player, m.Marks, m.Reason)
+}
+
+// NumOffTable - how many dice left the table on the roll
+func (dr DiceRoll) NumOffTable() int {
+	return ndice(dr.OffTable)
+}
+
+// RollValueSpecial - a roll that's better than any sum: a triple
 type RollValueSpecial int
 
 const (
@@ -57,13 +81,35 @@ const (
 	RollTriple                      = 111
 )
 
+// DiceTurn - a player's turn with the dice. Player is who the turn counts
+// for; RolledBy is whoever's standing in and physically rolling for them, if
+// anyone is.
 type DiceTurn struct {
 	Player       string
+	RolledBy     string `json:",omitempty"`
+	ColorDie     int    `json:",omitempty"` // which of the dice is the colored one (Rules.ColorDie)
 	DiceVals     [3]int
 	Score        int
 	ScoreSpecial RollValueSpecial
 	NumRolls     int
 	Rolls        []DiceRoll
+	Settled      *Settlement `json:",omitempty"`
+}
+
+// Settlement - how a closed turn fared against the turn passed to the roller
+// (or the opening value, for the first turn), and who takes how many marks
+// for it. Loser is empty if nobody takes marks.
+type Settlement struct {
+	Player         string           `json:"player"`
+	Value          int              `json:"value"`
+	Special        RollValueSpecial `json:"special"`
+	Against        string           `json:"against"`
+	AgainstValue   int              `json:"against_value"`
+	AgainstSpecial RollValueSpecial `json:"against_special"`
+	Beat           bool             `json:"beat"`
+	Tie            bool             `json:"tie"`
+	Loser          string           `json:"loser"`
+	Marks          int              `json:"marks"`
 }
 
 func NewTurn(name string) DiceTurn {
@@ -71,7 +117,13 @@ func NewTurn(name string) DiceTurn {
 	return dt
 }
 
+// TurnValue - value of the roll under the default rules
 func (dr DiceRoll) TurnValue() (int, RollValueSpecial) {
+	return dr.TurnValueWith(DefaultRules())
+}
+
+// TurnValueWith - value of the roll under the given house rules
+func (dr DiceRoll) TurnValueWith(rules Rules) (int, RollValueSpecial) {
 	special := NothingSpecial
 	score := 0
 
@@ -87,8 +139,12 @@ func (dr DiceRoll) TurnValue() (int, RollValueSpecial) {
 	} else {
 		score = 0
 		for i := 0; i < 3; i++ {
-			if 6 != dr.RollResults[i] {
-				score += dr.RollResults[i]
+			if DieVal0 != dr.RollResults[i] || !rules.SixIsZero {
+				if i == rules.ColorDie {
+					score += dr.RollResults[i] * rules.ColorDieFactor
+				} else {
+					score += dr.RollResults[i]
+				}
 			}
 		}
 		if score <= 0 {
@@ -99,7 +155,11 @@ func (dr DiceRoll) TurnValue() (int, RollValueSpecial) {
 }
 
 func (dr DiceRoll) TurnValueString() string {
-	switch score, special := dr.TurnValue(); special {
+	return dr.TurnValueStringWith(DefaultRules())
+}
+
+func (dr DiceRoll) TurnValueStringWith(rules Rules) string {
+	switch score, special := dr.TurnValueWith(rules); special {
 	case NothingSpecial:
 		return fmt.Sprintf("%d", score)
 	case RollTriple:
@@ -114,11 +174,32 @@ func (dr DiceRoll) TurnValueString() string {
 	return fmt.Sprintf("ERROR")
 }
 
+// RollString - the dice the turn ended with; the colored die is in braces
 func (dt *DiceTurn) RollString() string {
-	s := fmt.Sprintf("[%d][%d][%d]", dt.DiceVals[0], dt.DiceVals[1], dt.DiceVals[2])
+	s := ""
+	for d, val := range dt.DiceVals {
+		s += dt.dieString(d, val)
+	}
 	return s
 }
 
+// dieString - a die's value, in braces if it's the colored die
+func (dt DiceTurn) dieString(d int, val int) string {
+	if d == dt.ColorDie {
+		return fmt.Sprintf("{%d}", val)
+	}
+	return fmt.Sprintf("[%d]", val)
+}
+
+// ColorValue - what the colored die shows after the last roll, or 0 if
+// there hasn't been one
+func (dt DiceTurn) ColorValue() int {
+	if dt.NumRolls < 1 {
+		return 0
+	}
+	return dt.Rolls[dt.NumRolls-1].RollResults[dt.ColorDie]
+}
+
 // TurnValue - the sum of the dice of the last roll in the turn. Note that This
 // is the 'raw' score, not the value that should be added to the player's
 // score! This is the value to compare to the TurnValue of the prior player's
@@ -155,8 +236,13 @@ func (dt *DiceTurn) RollString() string {
 	return tval
 } */
 
-// CloseTurn - sum up the score for the turn
+// CloseTurn - sum up the score for the turn under the default rules
 func (dt *DiceTurn) CloseTurn() int {
+	return dt.CloseTurnWith(DefaultRules())
+}
+
+// CloseTurnWith - sum up the score for the turn under the given rules
+func (dt *DiceTurn) CloseTurnWith(rules Rules) int {
 	if dt.NumRolls < 1 {
 		return -1
 	}
@@ -165,15 +251,123 @@ func (dt *DiceTurn) CloseTurn() int {
 	dt.DiceVals[1] = dt.Rolls[dt.NumRolls-1].RollResults[1]
 	dt.DiceVals[2] = dt.Rolls[dt.NumRolls-1].RollResults[2]
 
-	if dt.Score, dt.ScoreSpecial = dt.Rolls[dt.NumRolls-1].TurnValue(); dt.Score < 0 {
+	if dt.Score, dt.ScoreSpecial = dt.Rolls[dt.NumRolls-1].TurnValueWith(rules); dt.Score < 0 {
 		return -1
 	}
-	fmt.Printf("CloseTurn: %s %s %d\n", dt.Player, dt.RollString(), dt.Score)
 	return 0
 }
 
+// Rank - order turn values; lower is better. Triples beat everything: 555,
+// then 666, then 111 up through 444. Otherwise the lower sum wins.
+func Rank(score int, special RollValueSpecial) int {
+	switch special {
+	case RollTripleFive:
+		return -8
+	case RollTripleSix:
+		return -7
+	case RollTriple:
+		return score - 7
+	}
+	return score
+}
+
+// ValueName - how to say a value out loud
+func ValueName(score int, special RollValueSpecial) string {
+	switch special {
+	case RollTriple:
+		return fmt.Sprintf("Triple %d", score)
+	case RollTripleFive:
+		return "Triple-Five"
+	case RollTripleSix:
+		return "Triple-Six"
+	}
+	return fmt.Sprintf("%d", score)
+}
+
+// winnerMarks - marks the loser takes when the winner has the given value
+func (r Rules) winnerMarks(wscore int, wspecial RollValueSpecial, lscore int) int {
+	switch wspecial {
+	case RollTripleFive:
+		return r.TripleFiveMarks
+	case RollTripleSix:
+		return r.TripleSixMarks
+	case RollTriple:
+		return r.TripleMarks
+	}
+	return lscore - wscore
+}
+
+// CloseTurnAgainst - close the turn and settle it against prev, the turn
+// whose value the roller had to beat. A nil prev means this is the first turn
+// of the game, played against the opening value. If the roller beats it, the
+// previous roller takes the marks (nobody, for the opening); otherwise the
+// roller does. Ties go against the roller.
+func (dt *DiceTurn) CloseTurnAgainst(rules Rules, prev *DiceTurn) (Settlement, error) {
+	if dt.CloseTurnWith(rules) < 0 {
+		return Settlement{}, fmt.Errorf("%s has no roll to settle", dt.Player)
+	}
+
+	st := Settlement{Player: dt.Player, Value: dt.Score, Special: dt.ScoreSpecial,
+		AgainstValue: rules.OpeningValue, AgainstSpecial: NothingSpecial}
+	if prev != nil {
+		st.Against = prev.Player
+		st.AgainstValue, st.AgainstSpecial = prev.Score, prev.ScoreSpecial
+	}
+
+	mine, theirs := Rank(st.Value, st.Special), Rank(st.AgainstValue, st.AgainstSpecial)
+	switch {
+	case mine == theirs:
+		st.Tie = true
+		st.Loser = dt.Player
+		st.Marks = rules.TieMarks
+	case mine < theirs:
+		st.Beat = true
+		st.Loser = st.Against
+		st.Marks = rules.winnerMarks(st.Value, st.Special, st.AgainstValue)
+	default:
+		st.Loser = dt.Player
+		st.Marks = rules.winnerMarks(st.AgainstValue, st.AgainstSpecial, st.Value)
+	}
+	if st.Loser == "" {
+		st.Marks = 0
+	}
+
+	dt.Settled = &st
+	return st, nil
+}
+
+func (st Settlement) String() string {
+	against := st.Against
+	if against == "" {
+		against = "the opening"
+	}
+	s := fmt.Sprintf("%s's %s vs %s's %s: ", st.Player, ValueName(st.Value, st.Special),
+		against, ValueName(st.AgainstValue, st.AgainstSpecial))
+	switch {
+	case st.Tie:
+		s += "tie"
+	case st.Beat:
+		s += "beat it"
+	default:
+		s += "didn't beat it"
+	}
+	if st.Loser == "" {
+		return s + "; no marks"
+	}
+	return s + fmt.Sprintf("; %s takes %d", st.Loser, st.Marks)
+}
+
+// Who - the player the turn counts for, and who's rolling for them if
+// that's somebody else
+func (dt DiceTurn) Who() string {
+	if dt.RolledBy == "" {
+		return dt.Player
+	}
+	return fmt.Sprintf("%s (rolled by %s)", dt.Player, dt.RolledBy)
+}
+
 func (dt DiceTurn) String() string {
-	s := fmt.Sprintf("%s's turn: ", dt.Player)
+	s := fmt.Sprintf("%s's turn: ", dt.Who())
 	if 0 == dt.NumRolls {
 		s += "has yet to roll"
 		return s
@@ -193,7 +387,7 @@ func (dt DiceTurn) String() string {
 			} else {
 				s += " "
 			}
-			s += fmt.Sprintf("[%d]", dr.RollResults[d])
+			s += dt.dieString(d, dr.RollResults[d])
 		}
 	}
 
@@ -263,8 +457,25 @@ func allkeptsame(dr DiceRoll) bool {
 	return true
 }
 
-// RollCheck - check a roll
+// RollCheck - check whether the dice in the toroll bitmap may be rolled next,
+// under the default rules. See RollCheckWith.
 func (dt DiceTurn) RollCheck(toroll int) error {
+	return dt.RollCheckWith(DefaultRules(), toroll)
+}
+
+// RollCheckWith - check whether the dice in the toroll bitmap may be rolled
+// next. This is the one place the rolling rules live; TurnRollWith uses it
+// too.
+//
+// Generally a player must keep at least one more die after each roll. The
+// wrinkles are all on the third roll: see thirdRollCheck.
+func (dt DiceTurn) RollCheckWith(rules Rules, toroll int) error {
+	if toroll&^AllDice != 0 {
+		return fmt.Errorf("Invalid dice bitmap 0b%03b", toroll)
+	}
+	if toroll == 0 {
+		return fmt.Errorf("Rolling no dice is not a roll")
+	}
 	switch dt.NumRolls {
 	case 0:
 		if toroll != AllDice {
@@ -275,50 +486,84 @@ func (dt DiceTurn) RollCheck(toroll int) error {
 		if toroll == AllDice {
 			return fmt.Errorf("Must keep at least one die on the second roll")
 		}
-		//prevkept := dt.Rolls[0].Kept
-		// if 0 != toroll
 	case 2:
-		firstkept := dt.Rolls[0].Kept
-		// If two dice were kept on the previous roll, then you can roll again only if
-		// you are going for triples
-		fmt.Printf("Roll %v: kept 0b%03b on roll 1\n", 1+dt.NumRolls, firstkept)
-		// Were two dice were kept in the first roll?
-		if 2 == ndice(firstkept) {
-			fmt.Printf("Kept two dice on roll 1\n")
-			if firstkept == toroll {
-				fmt.Printf("Rerolling previously kept two dice\n")
-				// Special case: can only reroll both kept dice IF you are now going for triple
-				// RULE CHECK: only for triple-fives?
-				rolling := DieID(^toroll & AllDice)
-				if dt.Rolls[1].RollResults[dieindex[rolling]] != 5 {
-					return fmt.Errorf("Can only reroll two previously kept if going for triple-fives")
-				}
-			} else if 0 == toroll&firstkept {
-				// Rerolling the same single die as in second roll. Only allowed if
-				// going for triples
-				if !allkeptsame(dt.Rolls[0]) {
-					return fmt.Errorf("Can only roll same single die (0b%03b) twice if going for triples", toroll)
-				}
-			} else {
-				// Rerolling one of the prior kept two; not allowed
-				return fmt.Errorf("Cannot reroll only one of previously kept two die (0b%03b)",
-					toroll&firstkept)
+		return dt.thirdRollCheck(rules, toroll)
+	default:
+		return fmt.Errorf("Cannot roll (%d rolls already)", dt.NumRolls)
+	}
+	return nil
+}
+
+// thirdRollCheck - the tricky one. Normally the player rolls a single die that
+// was rolled on the second roll, keeping the other(s). Exceptions, each
+// subject to the house rules:
+//   - kept two on roll 1: may roll that same single die again only if the kept
+//     dice match (going for triples)
+//   - the triple-five (or six) pickup: may pick up previously kept dice to
+//     roll two dice if the die left on the table is a 5 (or 6)
+func (dt DiceTurn) thirdRollCheck(rules Rules, toroll int) error {
+	firstkept := dt.Rolls[0].Kept
+	secondrolled := dt.Rolls[1].Rolled
+
+	if 0 != toroll&firstkept {
+		switch dt.pickupValue(toroll) {
+		case DieVal000:
+			if rules.PickupTripleFive {
+				return nil
 			}
-		} else {
-			// One die was kept on first roll.
-			// After keeping two dice, re-rolling the third. Allowed only if kept
-			// dice match (eg, going for triples)
-			if !allkeptsame(dt.Rolls[0]) {
-				return fmt.Errorf("Code TBD")
+		case DieVal0:
+			if rules.PickupTripleSix {
+				return nil
 			}
 		}
-		if 0 != firstkept&toroll {
-			//
+		return fmt.Errorf("Cannot reroll a kept die (0b%03b) unless going for %s",
+			toroll&firstkept, rules.pickupString())
+	}
+
+	if 1 == ndice(secondrolled) {
+		// Rerolling the same single die as in second roll. Only allowed if
+		// going for triples
+		if !rules.RerollSingleDie {
+			return fmt.Errorf("Cannot roll the same single die (0b%03b) twice", toroll)
+		}
+		if !allkeptsame(dt.Rolls[0]) && !rules.NonMatchingMayRoll {
+			return fmt.Errorf("Can only roll same single die (0b%03b) twice if going for triples", toroll)
 		}
+	} else if toroll == secondrolled {
+		return fmt.Errorf("Must keep at least one die from the second roll")
 	}
 	return nil
 }
 
+// TripleFivePickup - is rolling toroll on the third roll a pickup of
+// previously kept dice to go for triple-fives? That's rolling exactly two
+// dice, at least one of them kept, with the die staying on the table a 5.
+func (dt DiceTurn) TripleFivePickup(toroll int) bool {
+	return DieVal000 == dt.pickupValue(toroll)
+}
+
+// pickupValue - if rolling toroll on the third roll picks up previously kept
+// dice to roll two, the value of the die staying on the table; otherwise 0
+func (dt DiceTurn) pickupValue(toroll int) int {
+	if dt.NumRolls != 2 || ndice(toroll) != 2 || 0 == toroll&dt.Rolls[0].Kept {
+		return 0
+	}
+	staying := DieID(^toroll & AllDice)
+	return dt.Rolls[1].RollResults[dieindex[staying]]
+}
+
+func (r Rules) pickupString() string {
+	switch {
+	case r.PickupTripleFive && r.PickupTripleSix:
+		return "triple-fives or triple-sixes"
+	case r.PickupTripleSix:
+		return "triple-sixes"
+	case r.PickupTripleFive:
+		return "triple-fives"
+	}
+	return "nothing (no pickups in this house)"
+}
+
 func (dr DiceRoll) IsConsec() bool {
 	dsort := dr.RollResults[0:]
 	//sort.Slice(dsort, func(i, j int) bool { return dsort[i] < dsort[j] })
@@ -329,31 +574,73 @@ func (dr DiceRoll) IsConsec() bool {
 	return false
 }
 
-// ConsecsScore - return any consecutivds points UP TO the specified roll
+// ConsecsScore - return any consecutivds points UP TO the specified roll, under
+// the default rules
 func (d DiceTurn) ConsecScore(roll int) (int, error) {
+	return d.ConsecScoreWith(DefaultRules(), roll)
+}
+
+// ConsecScoreWith - return any consecutives points UP TO the specified roll
+func (d DiceTurn) ConsecScoreWith(rules Rules, roll int) (int, error) {
 	if roll > d.NumRolls {
 		return 0, fmt.Errorf("ERROR: requested roll %d > number of rolls %d",
 			roll, d.NumRolls)
 	}
 
-	roll -= 1
 	cscore := 0
-	if d.Rolls[roll].IsConsec() {
-		cscore = 2
-	}
-
-	if roll > 0 {
-		if pc, e := d.ConsecScore(roll); e != nil {
-			return 0, e
-		} else {
-			// cscore = cscore << 2
-			cscore += pc
+	cval := rules.ConsecMarks
+	for r := 0; r < roll; r++ {
+		if d.Rolls[r].IsConsec() {
+			cscore += cval
+			if rules.ConsecDoubling {
+				cval = cval << 1
+			}
 		}
 	}
 
 	return cscore, nil
 }
 
-func (d DiceTurn) TurnRoll(rolled int, d1 int, d2 int, d3 int) int {
-	return 0
+// TurnRoll - roll the dice in the rolled bitmap, under the default rules. See
+// TurnRollWith.
+func (dt *DiceTurn) TurnRoll(rolled int, d1 int, d2 int, d3 int) error {
+	return dt.TurnRollWith(DefaultRules(), rolled, d1, d2, d3)
+}
+
+// TurnRollWith - roll the dice in the rolled bitmap, which came up d1, d2 and
+// d3 (values for dice not rolled are ignored; they stay as they were). This is
+// the one way a roll gets into a turn: it's checked with RollCheckWith, the
+// previous roll learns what was kept from it, and after the third roll the
+// turn is closed. Nothing changes if the roll isn't allowed.
+func (dt *DiceTurn) TurnRollWith(rules Rules, rolled int, d1 int, d2 int, d3 int) error {
+	if err := dt.RollCheckWith(rules, rolled); err != nil {
+		return err
+	}
+	vals := [3]int{d1, d2, d3}
+	for d, val := range vals {
+		if rolled&(Die0<<d) != 0 && (val < 1 || val > 6) {
+			return fmt.Errorf("Invalid value for die %d: %d", d, val)
+		}
+	}
+
+	dr := DiceRoll{Rolled: rolled}
+	if dt.NumRolls > 0 {
+		// What's not rolled now was kept then
+		prev := &dt.Rolls[dt.NumRolls-1]
+		prev.Kept = ^rolled & AllDice
+		dr.RollResults = prev.RollResults
+	}
+	for d, val := range vals {
+		if rolled&(Die0<<d) != 0 {
+			dr.RollResults[d] = val
+		}
+	}
+	dr.Consecs = dr.IsConsec()
+
+	dt.Rolls = append(dt.Rolls[:dt.NumRolls], dr)
+	dt.NumRolls++
+	if dt.NumRolls == 3 {
+		dt.CloseTurnWith(rules)
+	}
+	return nil
 }
@@ -2,6 +2,7 @@ package diceturn
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 )
 
@@ -97,51 +98,190 @@ func TestRollCheck(t *testing.T) {
 		//}
 	}
 
-	// THIRD roll - the tough one
-	// * Typical: held 1 die on roll 1, will hold one from roll 2
-	dt.Rolls[0].Kept = Die0
-	dt.Rolls[0].Rolled = AllDice
-	dt.Rolls[0].RollResults = [3]int{1, 2, 4}
+	// THIRD roll: see TestThirdRollCheck
+}
 
-	dt.Rolls = append(dt.Rolls, DiceRoll{Rolled: Die1 | Die2, Kept: 0,
-		RollResults: [3]int{0, 2, 5}})
-	dt.NumRolls = 2
+// thirdturn - a turn that's had two rolls, keeping firstkept after the first
+func thirdturn(firstkept int, r1 [3]int, r2 [3]int) DiceTurn {
+	return DiceTurn{NumRolls: 2, Rolls: []DiceRoll{
+		{Rolled: AllDice, RollResults: r1, Kept: firstkept},
+		{Rolled: ^firstkept & AllDice, RollResults: r2},
+	}}
+}
 
-	// Now: check to see what can be Rolled - should just be third die
-	echeck(t, func() error { return dt.RollCheck(Die2) },
-		"rolling a single unrolled die on turn 3", false)
+func TestThirdRollCheck(t *testing.T) {
+	type rtest struct {
+		descr     string
+		firstkept int
+		r1, r2    [3]int
+		allowed   []int
+	}
+	rtests := []rtest{
+		{"kept one, 5 showing", Die0, [3]int{1, 2, 4}, [3]int{1, 2, 5},
+			[]int{Die1, Die2, Die0 | Die1}},
+		{"kept one, no 5", Die0, [3]int{1, 2, 4}, [3]int{1, 2, 3},
+			[]int{Die1, Die2}},
+		{"kept one (Die1)", Die1, [3]int{4, 1, 2}, [3]int{3, 1, 3},
+			[]int{Die0, Die2}},
+		{"kept one six, 5 showing", Die2, [3]int{6, 6, 6}, [3]int{5, 1, 6},
+			[]int{Die0, Die1, Die1 | Die2}},
+		// No picking up for triple-sixes in the default house
+		{"kept one six, another 6 showing", Die2, [3]int{6, 6, 6}, [3]int{6, 1, 6},
+			[]int{Die0, Die1}},
+		{"kept matching two, 5 rolled", Die0 | Die1, [3]int{3, 3, 4}, [3]int{3, 3, 5},
+			[]int{Die2, Die0 | Die1}},
+		{"kept matching two (Die0, Die2)", Die0 | Die2, [3]int{4, 1, 4}, [3]int{4, 5, 4},
+			[]int{Die1, Die0 | Die2}},
+		{"kept two fives", Die1 | Die2, [3]int{2, 5, 5}, [3]int{3, 5, 5},
+			[]int{Die0, Die0 | Die1, Die0 | Die2}},
+		// The genius who kept two non-matching dice after roll 1 can't roll
+		// the third die again, but can still pick up for triple-fives
+		{"kept non-matching two, 5 rolled", Die0 | Die1, [3]int{1, 2, 4}, [3]int{1, 2, 5},
+			[]int{Die0 | Die1}},
+		{"kept non-matching two", Die0 | Die1, [3]int{1, 2, 4}, [3]int{1, 2, 3},
+			[]int{}},
+	}
 
-	// Disallow previously kept die, individually and together
-	for _, d := range []DieID{Die0, Die1} {
-		echeck(t, func() error { return dt.RollCheck(int(d)) },
-			fmt.Sprintf("rerolling a previously kept die (%s) on roll 3", diename[d]), true)
-	}
-	echeck(t, func() error { return dt.RollCheck(int(Die0 | Die1)) },
-		fmt.Sprintf("rerolling both previously kept dice on roll 3"), true)
-
-	// Never actually happens: genius kept two non-matching dice after turn 1, wants
-	// to reroll third die on turn3
-	dt.Rolls[0].Kept = Die0 | Die1
-	dt.Rolls[0].Rolled = AllDice
-	dt.Rolls[0].RollResults = [3]int{1, 2, 4}
-	dt.Rolls[1].Rolled = Die2
-	dt.Rolls[1].RollResults = [3]int{0, 0, 5}
-	dt.NumRolls = 2
-
-	// No actual combinations should work - the turn must end!
-	// ARGUMENTATIVE: why not allow this idiot to roll?
-	for _, d := range []DieID{Die0, Die1, Die2, Die0 | Die1, Die1 | Die2, Die2 | Die0, Die0 | Die1 | Die2} {
-		echeck(t, func() error { return dt.RollCheck(int(d)) },
-			fmt.Sprintf("rolling anything (0x%03b) on roll 3 after keeping 2 non-matching on roll 1", d),
-			true)
+	for _, rt := range rtests {
+		dt := thirdturn(rt.firstkept, rt.r1, rt.r2)
+		for toroll := Die0; toroll <= AllDice; toroll++ {
+			experr := true
+			for _, a := range rt.allowed {
+				if a == toroll {
+					experr = false
+				}
+			}
+			echeck(t, func() error { return dt.RollCheck(toroll) },
+				fmt.Sprintf("%s: roll of 0b%03b on roll 3", rt.descr, toroll), experr)
+		}
+	}
+}
+
+func TestTripleFivePickup(t *testing.T) {
+	dt := thirdturn(Die0, [3]int{1, 2, 4}, [3]int{1, 5, 3})
+	if !dt.TripleFivePickup(Die0 | Die2) {
+		t.Errorf("Rolling two dice around a 5 should be a triple-five pickup")
+	}
+	if dt.TripleFivePickup(Die2) {
+		t.Errorf("Rolling a single unkept die is not a pickup")
+	}
+	if dt.TripleFivePickup(Die0 | Die1) {
+		t.Errorf("Leaving a 3 on the table is not a triple-five pickup")
+	}
+	dt.NumRolls = 1
+	if dt.TripleFivePickup(Die0 | Die2) {
+		t.Errorf("Only the third roll can be a pickup")
+	}
+}
+
+// closedturn - a one-roll turn with the given results
+func closedturn(player string, r [3]int) DiceTurn {
+	return DiceTurn{Player: player, NumRolls: 1,
+		Rolls: []DiceRoll{{Rolled: AllDice, RollResults: r}}}
+}
+
+func TestCloseTurnAgainst(t *testing.T) {
+	rules := DefaultRules()
+	type stest struct {
+		descr string
+		mine  [3]int
+		prev  *[3]int
+		loser string
+		marks int
+	}
+	stests := []stest{
+		{"beat the opening", [3]int{1, 2, 4}, nil, "", 0},
+		{"tie the opening", [3]int{5, 5, 4}, nil, "Me", 1},
+		{"beat a 9 with a 4", [3]int{1, 6, 3}, &[3]int{2, 3, 4}, "Prev", 5},
+		{"lose to a 4 with a 9", [3]int{2, 3, 4}, &[3]int{1, 6, 3}, "Me", 5},
+		{"tie a 7", [3]int{1, 2, 4}, &[3]int{3, 4, 6}, "Me", 1},
+		{"triple beats a 1", [3]int{4, 4, 4}, &[3]int{1, 6, 6}, "Prev", rules.TripleMarks},
+		{"triple-five beats triple-six", [3]int{5, 5, 5}, &[3]int{6, 6, 6}, "Prev", rules.TripleFiveMarks},
+		{"triple two loses to triple-six", [3]int{2, 2, 2}, &[3]int{6, 6, 6}, "Me", rules.TripleSixMarks},
+		{"triple one beats triple two", [3]int{1, 1, 1}, &[3]int{2, 2, 2}, "Prev", rules.TripleMarks},
+	}
+
+	for _, st := range stests {
+		dt := closedturn("Me", st.mine)
+		var prev *DiceTurn
+		if st.prev != nil {
+			pt := closedturn("Prev", *st.prev)
+			pt.CloseTurn()
+			prev = &pt
+		}
+		res, err := dt.CloseTurnAgainst(rules, prev)
+		if err != nil {
+			t.Errorf("%s: %v", st.descr, err)
+			continue
+		}
+		if res.Loser != st.loser || res.Marks != st.marks {
+			t.Errorf("%s: %s takes %d, expected %s takes %d (%v)",
+				st.descr, res.Loser, res.Marks, st.loser, st.marks, res)
+		} else {
+			t.Logf("%s: %v", st.descr, res)
+		}
+		if dt.Settled == nil || *dt.Settled != res {
+			t.Errorf("%s: settlement not recorded in turn", st.descr)
+		}
 	}
 
-	// Special 1: previously kept two matching die after the first roll, allow reroll on third
+	dt := NewTurn("Nobody")
+	if _, err := dt.CloseTurnAgainst(rules, nil); err == nil {
+		t.Errorf("Settled a turn with no rolls")
+	}
+}
+
+func TestColorDie(t *testing.T) {
+	dt := closedturn("Me", [3]int{4, 2, 1})
+	dt.ColorDie = 1
+	dt.CloseTurn()
+	if s := dt.RollString(); s != "[4]{2}[1]" {
+		t.Errorf("RollString %s doesn't show the colored die", s)
+	}
+	if s := dt.String(); !strings.Contains(s, "{2}") {
+		t.Errorf("String %s doesn't show the colored die", s)
+	}
+	if v := dt.ColorValue(); v != 2 {
+		t.Errorf("Colored die shows %d, expected 2", v)
+	}
+}
 
-	//
-	// if e := dt.RollCheck(Die1); e != nil {
-	// 	t.Logf("Properly diallowed reroll of %s on second roll (%v)", diename[Die0], e)
-	// } else {
-	// 	t.Errorf("Improperly allowed reroll of %s on second roll", diename[Die0])
-	// }
+func TestTurnRoll(t *testing.T) {
+	dt := NewTurn("Me")
+	if err := dt.TurnRoll(Die0, 1, 0, 0); err == nil {
+		t.Errorf("Rolled one die on the first roll")
+	}
+	if err := dt.TurnRoll(AllDice, 1, 7, 3); err == nil {
+		t.Errorf("Rolled a 7")
+	}
+	if dt.NumRolls != 0 || len(dt.Rolls) != 0 {
+		t.Fatalf("Refused rolls changed the turn: %v", dt)
+	}
+
+	if err := dt.TurnRoll(AllDice, 2, 2, 5); err != nil {
+		t.Fatalf("First roll: %v", err)
+	}
+	// Values for dice that aren't rolled don't matter
+	if err := dt.TurnRoll(Die1|Die2, 6, 3, 4); err != nil {
+		t.Fatalf("Second roll: %v", err)
+	}
+	if k := dt.Rolls[0].Kept; k != Die0 {
+		t.Errorf("First roll kept 0b%03b, expected 0b001", k)
+	}
+	if r := dt.Rolls[1].RollResults; r != [3]int{2, 3, 4} || !dt.Rolls[1].Consecs {
+		t.Errorf("Second roll came up %v, expected consecutives 2 3 4", r)
+	}
+	if dt.Settled != nil || dt.Score != 0 {
+		t.Errorf("Turn closed too soon: %v", dt)
+	}
+
+	if err := dt.TurnRoll(Die2, 0, 0, 6); err != nil {
+		t.Fatalf("Third roll: %v", err)
+	}
+	if dt.DiceVals != [3]int{2, 3, 6} || dt.Score != 5 {
+		t.Errorf("Turn not closed after the third roll: %v (%d)", dt, dt.Score)
+	}
+	if err := dt.TurnRoll(Die2, 0, 0, 1); err == nil {
+		t.Errorf("Rolled a fourth time")
+	}
 }
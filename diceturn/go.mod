@@ -1,3 +1,5 @@
 module wojones.com/src/diceturn
 
 go 1.18
+
+require gopkg.in/yaml.v2 v2.4.0
@@ -0,0 +1,238 @@
+package diceturn
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Turn notation: a whole turn on one line, to paste into chat or to copy a
+// turn scored on paper into a game. For example
+//
+//	Alice (Bob) {2}: +2+2+5 22+1! 22+3 = 7
+//
+// is Alice's turn, rolled for her by Bob, with die 2 the colored die. Each
+// roll is the three dice in order (die 0 first) as they stood after it: a
+// die rolled that time has a + before it, and a ! after it if it left the
+// table (the value is then what it was rolled again to). So Alice rolled
+// 2 2 5; kept the 2s and rolled a 1, which left the table; then rolled that
+// die again, for a 3. The dice a roll kept are the ones the next roll
+// doesn't roll. "= 7" says what the turn ended on; it's missing while the
+// turn's still going. Names are written as they are if they're all letters,
+// digits and -_.' and otherwise quoted, Go style. Settlements and marks
+// belong to the game, not the turn, and aren't in the notation.
+
+// Format - the turn in turn notation
+func Format(dt DiceTurn) string {
+	s := notationName(dt.Player)
+	if dt.RolledBy != "" {
+		s += " (" + notationName(dt.RolledBy) + ")"
+	}
+	if dt.ColorDie != 0 {
+		s += fmt.Sprintf(" {%d}", dt.ColorDie)
+	}
+	s += ":"
+	for i := 0; i < dt.NumRolls; i++ {
+		dr := dt.Rolls[i]
+		s += " "
+		for d := 0; d < 3; d++ {
+			die := Die0 << d
+			if dr.Rolled&die != 0 {
+				s += "+"
+			}
+			s += strconv.Itoa(dr.RollResults[d])
+			if dr.OffTable&die != 0 {
+				s += "!"
+			}
+		}
+	}
+	if dt.closed() {
+		s += " = " + ValueName(dt.Score, dt.ScoreSpecial)
+	}
+	return s
+}
+
+// closed - has the turn been summed up? CloseTurnWith sets the dice it ended on.
+func (dt DiceTurn) closed() bool {
+	return dt.NumRolls > 0 && dt.DiceVals != [3]int{}
+}
+
+// bareName - can the name go in the notation without quotes?
+func bareName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if !nameRune(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func nameRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("-_.'", r)
+}
+
+func notationName(name string) string {
+	if bareName(name) {
+		return name
+	}
+	return strconv.Quote(name)
+}
+
+// Parse - read a turn in turn notation, under the default rules. See
+// ParseWith.
+func Parse(s string) (DiceTurn, error) {
+	return ParseWith(DefaultRules(), s)
+}
+
+// ParseWith - read a turn in turn notation. The rolls go into the turn with
+// TurnRollWith, so they have to be ones the house rules allow, and a turn
+// that stood is closed under them: its value has to be the one given.
+func ParseWith(rules Rules, s string) (DiceTurn, error) {
+	p := &notationParser{s: strings.TrimSpace(s)}
+	player, err := p.name()
+	if err != nil {
+		return DiceTurn{}, err
+	}
+	dt := NewTurn(player)
+	if p.skip('(') {
+		if dt.RolledBy, err = p.name(); err != nil {
+			return DiceTurn{}, err
+		}
+		if !p.skip(')') {
+			return DiceTurn{}, p.errorf("expected ) after who rolled")
+		}
+	}
+	if p.skip('{') {
+		if p.rest() == "" || p.rest()[0] < '0' || p.rest()[0] > '2' {
+			return DiceTurn{}, p.errorf("the colored die must be 0, 1 or 2")
+		}
+		dt.ColorDie = int(p.rest()[0] - '0')
+		p.pos++
+		if !p.skip('}') {
+			return DiceTurn{}, p.errorf("expected } after the colored die")
+		}
+	}
+	if !p.skip(':') {
+		return DiceTurn{}, p.errorf("expected : after the player")
+	}
+
+	rolls, value := p.rest(), ""
+	stood := false
+	if i := strings.Index(rolls, "="); i >= 0 {
+		rolls, value, stood = rolls[:i], rolls[i+1:], true
+	}
+	for n, roll := range strings.Fields(rolls) {
+		if err := dt.parseRoll(rules, roll); err != nil {
+			return DiceTurn{}, fmt.Errorf("roll %d (%s): %v", n+1, roll, err)
+		}
+	}
+	if !stood {
+		return dt, nil
+	}
+	if dt.NumRolls == 0 {
+		return DiceTurn{}, fmt.Errorf("%s can't stand without rolling", dt.Player)
+	}
+	if dt.NumRolls < 3 {
+		dt.CloseTurnWith(rules)
+	}
+	if want, got := strings.Join(strings.Fields(value), " "), ValueName(dt.Score, dt.ScoreSpecial); want != got {
+		return DiceTurn{}, fmt.Errorf("turn ends on %s, not %s", got, want)
+	}
+	return dt, nil
+}
+
+// parseRoll - add one roll in turn notation to the turn
+func (dt *DiceTurn) parseRoll(rules Rules, roll string) error {
+	rolled, off := 0, 0
+	var vals [3]int
+	i := 0
+	for d := 0; d < 3; d++ {
+		die := Die0 << d
+		if i < len(roll) && roll[i] == '+' {
+			rolled |= die
+			i++
+		}
+		if i >= len(roll) || roll[i] < '1' || roll[i] > '6' {
+			return fmt.Errorf("die %d needs a value from 1 to 6", d)
+		}
+		vals[d] = int(roll[i] - '0')
+		i++
+		if i < len(roll) && roll[i] == '!' {
+			if rolled&die == 0 {
+				return fmt.Errorf("die %d wasn't rolled, so it can't leave the table", d)
+			}
+			off |= die
+			i++
+		}
+	}
+	if i != len(roll) {
+		return fmt.Errorf("unexpected %q after the dice", roll[i:])
+	}
+	// Dice that weren't rolled have to be as they were
+	if dt.NumRolls > 0 {
+		prev := dt.Rolls[dt.NumRolls-1].RollResults
+		for d := 0; d < 3; d++ {
+			if rolled&(Die0<<d) == 0 && vals[d] != prev[d] {
+				return fmt.Errorf("die %d wasn't rolled, so it's still %d, not %d", d, prev[d], vals[d])
+			}
+		}
+	}
+	if err := dt.TurnRollWith(rules, rolled, vals[0], vals[1], vals[2]); err != nil {
+		return err
+	}
+	dt.Rolls[dt.NumRolls-1].OffTable = off
+	return nil
+}
+
+// notationParser - where we've got to reading the turn's header
+type notationParser struct {
+	s   string
+	pos int
+}
+
+func (p *notationParser) rest() string {
+	return p.s[p.pos:]
+}
+
+func (p *notationParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("turn notation at %d: %s", p.pos+1, fmt.Sprintf(format, args...))
+}
+
+// skip - skip spaces, then c if it's next
+func (p *notationParser) skip(c byte) bool {
+	for p.pos < len(p.s) && p.s[p.pos] == ' ' {
+		p.pos++
+	}
+	if p.pos < len(p.s) && p.s[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// name - a bare or quoted name
+func (p *notationParser) name() (string, error) {
+	p.skip(' ')
+	if strings.HasPrefix(p.rest(), "\"") {
+		q, err := strconv.QuotedPrefix(p.rest())
+		if err != nil {
+			return "", p.errorf("bad quoted name")
+		}
+		p.pos += len(q)
+		return strconv.Unquote(q)
+	}
+	end := strings.IndexFunc(p.rest(), func(r rune) bool { return !nameRune(r) })
+	if end < 0 {
+		end = len(p.rest())
+	}
+	if end == 0 {
+		return "", p.errorf("expected a name")
+	}
+	name := p.rest()[:end]
+	p.pos += end
+	return name, nil
+}
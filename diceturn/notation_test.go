@@ -0,0 +1,138 @@
+package diceturn
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNotation(t *testing.T) {
+	dt := NewTurn("Alice")
+	dt.RolledBy = "Bob"
+	dt.ColorDie = 2
+	for _, r := range [][4]int{{AllDice, 2, 2, 5}, {Die2, 0, 0, 1}, {Die2, 0, 0, 3}} {
+		if err := dt.TurnRoll(r[0], r[1], r[2], r[3]); err != nil {
+			t.Fatalf("Rolling %v: %v", r, err)
+		}
+	}
+	dt.Rolls[1].OffTable = Die2
+	const want = "Alice (Bob) {2}: +2+2+5 22+1! 22+3 = 7"
+	if s := Format(dt); s != want {
+		t.Errorf("Format: %s, expected %s", s, want)
+	}
+	got, err := Parse(want)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if !reflect.DeepEqual(got, dt) {
+		t.Errorf("Parse: %+v, expected %+v", got, dt)
+	}
+
+	for _, tc := range []struct {
+		s    string
+		want string // formatted, if it parses
+	}{
+		{"Me:", "Me:"},
+		{"  Me :  +1+2+4   ", "Me: +1+2+4"},
+		{"Me: +1+2+4 = 7", "Me: +1+2+4 = 7"},
+		{"Me: +6+6+6 =  Triple-Six", "Me: +6+6+6 = Triple-Six"},
+		{"Me: +4+4+1 44+4 = Triple   4", "Me: +4+4+1 44+4 = Triple 4"},
+		{"Me: +2+2+5 2+3+1 2+41", "Me: +2+2+5 2+3+1 2+41 = 7"},
+		{`"Mary Ann" ("J:R"): +1+2+4`, `"Mary Ann" ("J:R"): +1+2+4`},
+		{"Me: +1+2+4 = 8", ""},            // wrong value
+		{"Me: = 7", ""},                   // stood without rolling
+		{"Me: 1+2+4", ""},                 // first roll has to roll everything
+		{"Me: +1+2+4 +1+2+4", ""},         // second has to keep one
+		{"Me: +1+2+4 2+3+5", ""},          // die 0 wasn't rolled
+		{"Me: +1+2+4 1!+3+5", ""},         // only rolled dice leave the table
+		{"Me: +1+2+7", ""},                // no such die
+		{"Me: +1+2+4+5", ""},              // four dice
+		{"Me {3}: +1+2+4", ""},            // no die 3
+		{"Me (You: +1+2+4", ""},           // unclosed
+		{"Mary Ann: +1+2+4", ""},          // space in a bare name
+		{": +1+2+4", ""},                  // nobody
+		{"Me: +2+2+5 22+1 22+3 2+23", ""}, // four rolls
+	} {
+		dt, err := Parse(tc.s)
+		switch {
+		case tc.want == "" && err == nil:
+			t.Errorf("Parse(%q) didn't fail: %s", tc.s, Format(dt))
+		case tc.want != "" && err != nil:
+			t.Errorf("Parse(%q): %v", tc.s, err)
+		case tc.want != "" && Format(dt) != tc.want:
+			t.Errorf("Parse(%q) formats as %q, expected %q", tc.s, Format(dt), tc.want)
+		}
+	}
+
+	// Parsed under the rules the turn was played by
+	rules := DefaultRules()
+	rules.SixIsZero = false
+	if _, err := ParseWith(rules, "Me: +6+1+2 = 9"); err != nil {
+		t.Errorf("ParseWith sixes counting six: %v", err)
+	}
+	if _, err := Parse("Me: +6+1+2 = 9"); err == nil {
+		t.Errorf("Parse counted a six as six")
+	}
+}
+
+// FuzzParse - whatever parses formats as something that parses back to the
+// same turn, and formats the same again
+func FuzzParse(f *testing.F) {
+	for _, s := range []string{
+		"Alice (Bob) {2}: +2+2+5 22+1! 22+3 = 7",
+		"Me: +4+4+1 44+4 = Triple 4",
+		`"Mary Ann" ("J:R"): +1+2+4`,
+		"Me: +5+5+2 5+5+5",
+		"Me:",
+	} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		dt, err := Parse(s)
+		if err != nil {
+			return
+		}
+		formatted := Format(dt)
+		again, err := Parse(formatted)
+		if err != nil {
+			t.Fatalf("Parse(%q) formats as %q, which doesn't parse: %v", s, formatted, err)
+		}
+		if !reflect.DeepEqual(again, dt) {
+			t.Fatalf("Parse(%q) is %+v, but parsing %q is %+v", s, dt, formatted, again)
+		}
+		if f2 := Format(again); f2 != formatted {
+			t.Fatalf("%q formats as %q", formatted, f2)
+		}
+	})
+}
+
+// FuzzFormat - any turn played through TurnRoll parses back exactly
+func FuzzFormat(f *testing.F) {
+	f.Add("Alice", "Bob", uint8(2), []byte{7, 2, 2, 5, 0, 4, 0, 0, 1, 4, 4, 0, 0, 3, 0}, false)
+	f.Add("Me", "", uint8(0), []byte{7, 1, 2, 4, 0}, true)
+	f.Add("\"x\" (y)", "\xff", uint8(1), []byte{}, false)
+	f.Fuzz(func(t *testing.T, player string, roller string, color uint8, rolls []byte, stand bool) {
+		dt := NewTurn(player)
+		dt.RolledBy = roller
+		dt.ColorDie = int(color % 3)
+		// Five bytes a roll: the dice to roll, their values, what left the table
+		for ; len(rolls) >= 5; rolls = rolls[5:] {
+			rolled := int(rolls[0]) & AllDice
+			if err := dt.TurnRoll(rolled, int(rolls[1]%6)+1, int(rolls[2]%6)+1, int(rolls[3]%6)+1); err != nil {
+				continue
+			}
+			dt.Rolls[dt.NumRolls-1].OffTable = int(rolls[4]) & rolled
+		}
+		if stand && dt.NumRolls > 0 {
+			dt.CloseTurn()
+		}
+
+		s := Format(dt)
+		got, err := Parse(s)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", s, err)
+		}
+		if !reflect.DeepEqual(got, dt) {
+			t.Fatalf("%q parses as %+v, expected %+v", s, got, dt)
+		}
+	})
+}
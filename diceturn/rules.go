@@ -0,0 +1,185 @@
+package diceturn
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Rules - a house-rules profile. Every house plays a little differently; the
+// rules engine consults one of these rather than hard-coding one house's way.
+// Fields missing from a rules file keep their DefaultRules values.
+type Rules struct {
+	Name string `json:"name" yaml:"name"`
+
+	// Third roll: may pick up previously kept dice to roll two dice if the die
+	// staying on the table is a 5 (triple-five) or a 6 (triple-six)?
+	PickupTripleFive bool `json:"pickup_triple_five" yaml:"pickup_triple_five"`
+	PickupTripleSix  bool `json:"pickup_triple_six" yaml:"pickup_triple_six"`
+
+	// Third roll, after keeping two on the first: may the single die rolled on
+	// the second roll be rolled again? Normally only if the kept dice match;
+	// NonMatchingMayRoll lets the idiot who kept two non-matching dice roll too.
+	RerollSingleDie    bool `json:"reroll_single_die" yaml:"reroll_single_die"`
+	NonMatchingMayRoll bool `json:"nonmatching_may_roll" yaml:"nonmatching_may_roll"`
+
+	// A 6 counts as zero (otherwise it counts as six)
+	SixIsZero bool `json:"six_is_zero" yaml:"six_is_zero"`
+
+	// Marks for consecutives; with ConsecDoubling each further consecutive
+	// roll in a turn is worth double the one before
+	ConsecMarks    int  `json:"consec_marks" yaml:"consec_marks"`
+	ConsecDoubling bool `json:"consec_doubling" yaml:"consec_doubling"`
+	// Consecutives are good for the roller: everyone else takes the marks.
+	// Some houses have the roller take them instead.
+	ConsecsToOthers bool `json:"consecs_to_others" yaml:"consecs_to_others"`
+
+	// Marks the roller takes for each die that leaves the table
+	OffTableMarks int `json:"off_table_marks" yaml:"off_table_marks"`
+
+	// The value the first roller of a game has to beat
+	OpeningValue int `json:"opening_value" yaml:"opening_value"`
+
+	// Marks that fill a chevron
+	ChevronSize int `json:"chevron_size" yaml:"chevron_size"`
+
+	// Settling a turn: the loser takes the difference between the two values,
+	// or these if the winner rolled a triple or it was a tie (which goes
+	// against the roller)
+	TripleMarks     int `json:"triple_marks" yaml:"triple_marks"`
+	TripleSixMarks  int `json:"triple_six_marks" yaml:"triple_six_marks"`
+	TripleFiveMarks int `json:"triple_five_marks" yaml:"triple_five_marks"`
+	TieMarks        int `json:"tie_marks" yaml:"tie_marks"`
+
+	// Which of the three dice is the colored one (0, 1 or 2), and how many
+	// times its value counts towards a roll: some houses count it double
+	ColorDie       int `json:"color_die" yaml:"color_die"`
+	ColorDieFactor int `json:"color_die_factor" yaml:"color_die_factor"`
+
+	// Who the dice can be passed to: anyone, or round the table (PassRotation),
+	// in whichever direction the first pass of the round went
+	PassMode string `json:"pass_mode" yaml:"pass_mode"`
+}
+
+// Pass modes
+const (
+	PassAnyone   = "anyone"
+	PassRotation = "rotation"
+)
+
+// DefaultRules - how we play at my house
+func DefaultRules() Rules {
+	return Rules{
+		Name:               "default",
+		PickupTripleFive:   true,
+		PickupTripleSix:    false,
+		RerollSingleDie:    true,
+		NonMatchingMayRoll: false,
+		SixIsZero:          true,
+		ConsecMarks:        2,
+		ConsecDoubling:     false,
+		ConsecsToOthers:    true,
+		OffTableMarks:      1,
+		OpeningValue:       14,
+		ChevronSize:        20,
+		TripleMarks:        5,
+		TripleSixMarks:     7,
+		TripleFiveMarks:    10,
+		TieMarks:           1,
+		ColorDie:           0,
+		ColorDieFactor:     1,
+		PassMode:           PassAnyone,
+	}
+}
+
+// Validate - make sure the profile makes sense
+func (r Rules) Validate() error {
+	if r.ConsecMarks < 0 {
+		return fmt.Errorf("consecutives can't be worth %d marks", r.ConsecMarks)
+	}
+	if r.OffTableMarks < 0 {
+		return fmt.Errorf("dice off the table can't be worth %d marks", r.OffTableMarks)
+	}
+	if r.OpeningValue <= 0 {
+		return fmt.Errorf("opening value must be positive, not %d", r.OpeningValue)
+	}
+	if r.ChevronSize <= 0 {
+		return fmt.Errorf("chevron size must be positive, not %d", r.ChevronSize)
+	}
+	if r.TripleMarks < 0 || r.TripleSixMarks < 0 || r.TripleFiveMarks < 0 || r.TieMarks < 0 {
+		return fmt.Errorf("marks for triples and ties can't be negative")
+	}
+	if r.ColorDie < 0 || r.ColorDie > 2 {
+		return fmt.Errorf("the colored die must be die 0, 1 or 2, not %d", r.ColorDie)
+	}
+	if r.ColorDieFactor < 1 {
+		return fmt.Errorf("the colored die must count at least once, not %d times", r.ColorDieFactor)
+	}
+	if r.PassMode != PassAnyone && r.PassMode != PassRotation {
+		return fmt.Errorf("pass mode must be %s or %s, not %q", PassAnyone, PassRotation, r.PassMode)
+	}
+	return nil
+}
+
+// ColorDieID - the colored die, as a DieID
+func (r Rules) ColorDieID() DieID {
+	return DieID(Die0 << r.ColorDie)
+}
+
+// UnmarshalJSON - start from the defaults so missing fields keep them, and
+// refuse fields that aren't rules, as YAML does: a misspelt rule would
+// otherwise quietly play the default
+func (r *Rules) UnmarshalJSON(b []byte) error {
+	type plain Rules
+	p := plain(DefaultRules())
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&p); err != nil {
+		return err
+	}
+	*r = Rules(p)
+	return nil
+}
+
+// ParseRules - read a rules profile; YAML if isyaml, otherwise JSON
+func ParseRules(buf []byte, isyaml bool) (Rules, error) {
+	r := DefaultRules()
+	var err error
+	if isyaml {
+		err = yaml.UnmarshalStrict(buf, &r)
+	} else {
+		err = json.Unmarshal(buf, &r)
+	}
+	if err != nil {
+		return Rules{}, err
+	}
+	return r, r.Validate()
+}
+
+// LoadRules - read a rules profile from a .json, .yaml or .yml file
+func LoadRules(path string) (Rules, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return Rules{}, err
+	}
+	ext := strings.ToLower(filepath.Ext(path))
+	r, err := ParseRules(buf, ext == ".yaml" || ext == ".yml")
+	if err != nil {
+		return Rules{}, fmt.Errorf("rules file %s: %v", path, err)
+	}
+	return r, nil
+}
+
+func (r Rules) String() string {
+	return fmt.Sprintf("Rules \"%s\": pickup 555 %v, 666 %v; reroll single %v (non-matching %v); "+
+		"six is zero %v; consecutives %d (doubling %v, to others %v); off table %d; opening %d; chevron %d; "+
+		"marks for triple %d, 666 %d, 555 %d, tie %d; color die %d (x%d); pass to %s",
+		r.Name, r.PickupTripleFive, r.PickupTripleSix, r.RerollSingleDie, r.NonMatchingMayRoll,
+		r.SixIsZero, r.ConsecMarks, r.ConsecDoubling, r.ConsecsToOthers, r.OffTableMarks, r.OpeningValue, r.ChevronSize,
+		r.TripleMarks, r.TripleSixMarks, r.TripleFiveMarks, r.TieMarks, r.ColorDie, r.ColorDieFactor, r.PassMode)
+}
@@ -0,0 +1,129 @@
+package diceturn
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseRules(t *testing.T) {
+	yr, err := ParseRules([]byte("name: smeck\npickup_triple_six: true\nconsec_marks: 3\n"), true)
+	if err != nil {
+		t.Fatalf("Failed to parse YAML rules: %v", err)
+	}
+	if yr.Name != "smeck" || !yr.PickupTripleSix || yr.ConsecMarks != 3 {
+		t.Errorf("YAML rules not read: %v", yr)
+	}
+	if !yr.PickupTripleFive || yr.OpeningValue != 14 {
+		t.Errorf("YAML rules lost defaults: %v", yr)
+	}
+
+	jr, err := ParseRules([]byte(`{"name": "danny", "six_is_zero": false}`), false)
+	if err != nil {
+		t.Fatalf("Failed to parse JSON rules: %v", err)
+	}
+	if jr.Name != "danny" || jr.SixIsZero || !jr.RerollSingleDie {
+		t.Errorf("JSON rules not read: %v", jr)
+	}
+
+	if _, err := ParseRules([]byte("consec_marks: -1\n"), true); err == nil {
+		t.Errorf("Allowed negative consecutives")
+	}
+	if _, err := ParseRules([]byte("pickup_anything: true\n"), true); err == nil {
+		t.Errorf("Allowed unknown rule in YAML")
+	}
+	if _, err := ParseRules([]byte(`{"chevron_sz": 15}`), false); err == nil {
+		t.Errorf("Allowed unknown rule in JSON")
+	}
+	var nested struct{ Rules Rules }
+	if err := json.Unmarshal([]byte(`{"Rules": {"pickup_anything": true}}`), &nested); err == nil {
+		t.Errorf("Allowed unknown rule in JSON inside something else")
+	}
+	if r, err := ParseRules([]byte("pass_mode: rotation\n"), true); err != nil || r.PassMode != PassRotation {
+		t.Errorf("Pass mode not read: %v (%v)", r, err)
+	}
+	if _, err := ParseRules([]byte("pass_mode: sideways\n"), true); err == nil {
+		t.Errorf("Allowed passing sideways")
+	}
+	if _, err := ParseRules([]byte("color_die: 3\n"), true); err == nil {
+		t.Errorf("Allowed a fourth die to be colored")
+	}
+}
+
+func TestRulesRoundTrip(t *testing.T) {
+	r := DefaultRules()
+	r.Name = "round trip"
+	r.ConsecDoubling = true
+	buf, err := json.Marshal(r)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	var back Rules
+	if err := json.Unmarshal(buf, &back); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if back != r {
+		t.Errorf("Rules changed in round trip: %v -> %v", r, back)
+	}
+}
+
+func TestRulesRollCheck(t *testing.T) {
+	// Kept a 1 on roll 1; a 6 is staying on the table
+	dt := thirdturn(Die0, [3]int{1, 2, 4}, [3]int{1, 3, 6})
+	sixes := DefaultRules()
+	echeck(t, func() error { return dt.RollCheckWith(sixes, Die0|Die1) },
+		"triple-six pickup under default rules", true)
+	sixes.PickupTripleSix = true
+	echeck(t, func() error { return dt.RollCheckWith(sixes, Die0|Die1) },
+		"triple-six pickup when the house allows it", false)
+
+	dt = thirdturn(Die0, [3]int{1, 2, 4}, [3]int{1, 3, 5})
+	nofives := DefaultRules()
+	nofives.PickupTripleFive = false
+	echeck(t, func() error { return dt.RollCheckWith(nofives, Die0|Die1) },
+		"triple-five pickup when the house forbids it", true)
+
+	// The genius with two non-matching dice
+	dt = thirdturn(Die0|Die1, [3]int{1, 2, 4}, [3]int{1, 2, 3})
+	idiot := DefaultRules()
+	idiot.NonMatchingMayRoll = true
+	echeck(t, func() error { return dt.RollCheckWith(idiot, Die2) },
+		"non-matching reroll when the house allows it", false)
+	idiot.RerollSingleDie = false
+	echeck(t, func() error { return dt.RollCheckWith(idiot, Die2) },
+		"single die reroll when the house forbids it", true)
+}
+
+func TestRulesScoring(t *testing.T) {
+	dr := DiceRoll{RollResults: [3]int{1, 6, 3}}
+	if v, _ := dr.TurnValue(); v != 4 {
+		t.Errorf("1/6/3 should be 4 with sixes as zero, not %d", v)
+	}
+	sixes := DefaultRules()
+	sixes.SixIsZero = false
+	if v, _ := dr.TurnValueWith(sixes); v != 10 {
+		t.Errorf("1/6/3 should be 10 with sixes as six, not %d", v)
+	}
+	color := DefaultRules()
+	color.ColorDie = 2
+	color.ColorDieFactor = 2
+	if v, _ := dr.TurnValueWith(color); v != 7 {
+		t.Errorf("1/6/3 should be 7 with the 3 on the colored die counting double, not %d", v)
+	}
+	if v, _ := (DiceRoll{RollResults: [3]int{3, 3, 3}}).TurnValueWith(color); v != 3 {
+		t.Errorf("Triple 3 should be 3 whatever the colored die, not %d", v)
+	}
+
+	dt := DiceTurn{NumRolls: 3, Rolls: []DiceRoll{
+		{RollResults: [3]int{1, 2, 3}},
+		{RollResults: [3]int{4, 2, 3}},
+		{RollResults: [3]int{4, 5, 6}},
+	}}
+	if cs, _ := dt.ConsecScore(3); cs != 6 {
+		t.Errorf("Three consecutives should be 6, not %d", cs)
+	}
+	dbl := DefaultRules()
+	dbl.ConsecDoubling = true
+	if cs, _ := dt.ConsecScoreWith(dbl, 3); cs != 14 {
+		t.Errorf("Three doubling consecutives should be 14, not %d", cs)
+	}
+}
@@ -4,8 +4,11 @@ go 1.18
 
 replace (
 	wojones.com/src/dicegame => ./dicegame
+	wojones.com/src/diceodds => ./diceodds
 	wojones.com/src/dicescore => ./dicescore
 	wojones.com/src/diceturn => ./diceturn
+	wojones.com/src/simulate => ./simulate
+	wojones.com/src/tournament => ./tournament
 )
 
 require wojones.com/src/dicegame v0.0.0-00010101000000-000000000000
@@ -17,11 +20,17 @@ require (
 
 require wojones.com/src/diceturn v0.0.0-00010101000000-000000000000
 
+require wojones.com/src/simulate v0.0.0-00010101000000-000000000000
+
+require wojones.com/src/diceodds v0.0.0-00010101000000-000000000000
+
+require wojones.com/src/tournament v0.0.0-00010101000000-000000000000
+
 require (
 	github.com/cosiner/argv v0.1.0 // indirect
 	github.com/cpuguy83/go-md2man/v2 v2.0.0 // indirect
 	github.com/derekparker/trie v0.0.0-20200317170641-1fdf38b7b0e9 // indirect
-	github.com/flosch/pongo2 v0.0.0-20200913210552-0d938eb266f3 // indirect
+	github.com/flosch/pongo2 v0.0.0-20200913210552-0d938eb266f3
 	github.com/go-chi/chi v1.5.4
 	github.com/go-chi/chi/v5 v5.0.8
 	github.com/go-delve/delve v1.20.1 // indirect
@@ -0,0 +1,9 @@
+module wojones.com/src/simulate
+
+go 1.18
+
+replace wojones.com/src/diceturn => ../diceturn
+
+require wojones.com/src/diceturn v0.0.0-00010101000000-000000000000
+
+require gopkg.in/yaml.v2 v2.4.0 // indirect
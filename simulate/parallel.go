@@ -0,0 +1,49 @@
+package simulate
+
+import (
+	"runtime"
+	"sync"
+)
+
+// Parallel - play jobs 0 to jobs-1 over workers goroutines (0 for one per
+// CPU), and fold each one's result in as it finishes. Folds happen one at a
+// time, in whatever order the jobs finish, so anything that has to come out
+// the same every time (seeding, say) has to go by the job number, and
+// folding has to add up the same whatever the order. A job that fails isn't
+// folded; the first failure is returned once everything's done.
+func Parallel[T any](jobs int, workers int, play func(job int) (T, error), fold func(T)) error {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	todo := make(chan int)
+	go func() {
+		for job := 0; job < jobs; job++ {
+			todo <- job
+		}
+		close(todo)
+	}()
+
+	var mu sync.Mutex
+	var firstErr error
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range todo {
+				res, err := play(job)
+				mu.Lock()
+				if err != nil {
+					if firstErr == nil {
+						firstErr = err
+					}
+				} else {
+					fold(res)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return firstErr
+}
@@ -0,0 +1,207 @@
+package simulate
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"wojones.com/src/diceturn"
+)
+
+// Play lots of turns with a strategy and see how they come out. Turns are
+// played through diceturn, so they follow the house rules exactly. Dice never
+// leave the table here.
+
+// chunkSize - turns played from each seed. The turns are split into chunks,
+// seeded in order from the simulation's seed, so the results only depend on
+// the seed and the number of turns, not on how many workers played them.
+const chunkSize = 10000
+
+// Config - how big a simulation to run, and under which rules
+type Config struct {
+	Turns   int
+	Workers int   // goroutines to play the turns; 0 for one per CPU
+	Seed    int64 // 0 to seed from the clock
+	Rules   diceturn.Rules
+}
+
+// Outcome - the value a turn ended on
+type Outcome struct {
+	Value   int
+	Special diceturn.RollValueSpecial
+}
+
+func (o Outcome) String() string {
+	return diceturn.ValueName(o.Value, o.Special)
+}
+
+// Result - how the turns of a simulation came out
+type Result struct {
+	Strategy    string
+	Seed        int64
+	Turns       int
+	Rolls       int             // rolls made, over all the turns
+	Outcomes    map[Outcome]int // turns ending on each value
+	ConsecTurns int             // turns with consecutives in them
+	ConsecMarks int             // marks handed out for consecutives
+}
+
+func newResult(strategy string) Result {
+	return Result{Strategy: strategy, Outcomes: map[Outcome]int{}}
+}
+
+// add - fold another result into this one
+func (r *Result) add(o Result) {
+	r.Turns += o.Turns
+	r.Rolls += o.Rolls
+	for oc, n := range o.Outcomes {
+		r.Outcomes[oc] += n
+	}
+	r.ConsecTurns += o.ConsecTurns
+	r.ConsecMarks += o.ConsecMarks
+}
+
+// Ranked - the outcomes seen, best first
+func (r Result) Ranked() []Outcome {
+	ocs := make([]Outcome, 0, len(r.Outcomes))
+	for oc := range r.Outcomes {
+		ocs = append(ocs, oc)
+	}
+	sort.Slice(ocs, func(i, j int) bool {
+		return diceturn.Rank(ocs[i].Value, ocs[i].Special) < diceturn.Rank(ocs[j].Value, ocs[j].Special)
+	})
+	return ocs
+}
+
+// TripleRate - the fraction of turns that ended on a triple
+func (r Result) TripleRate() float64 {
+	n := 0
+	for oc, count := range r.Outcomes {
+		if oc.Special != diceturn.NothingSpecial {
+			n += count
+		}
+	}
+	return r.rate(n)
+}
+
+// ConsecRate - the fraction of turns with consecutives in them
+func (r Result) ConsecRate() float64 {
+	return r.rate(r.ConsecTurns)
+}
+
+// MeanValue - the average value of the turns that didn't end on a triple
+func (r Result) MeanValue() float64 {
+	sum, n := 0, 0
+	for oc, count := range r.Outcomes {
+		if oc.Special == diceturn.NothingSpecial {
+			sum += oc.Value * count
+			n += count
+		}
+	}
+	if n == 0 {
+		return 0
+	}
+	return float64(sum) / float64(n)
+}
+
+func (r Result) rate(n int) float64 {
+	if r.Turns == 0 {
+		return 0
+	}
+	return float64(n) / float64(r.Turns)
+}
+
+func (r Result) String() string {
+	s := fmt.Sprintf("Strategy %s: %d turns (seed %d), %.2f rolls a turn\n", r.Strategy, r.Turns, r.Seed,
+		float64(r.Rolls)/float64(r.Turns))
+	s += fmt.Sprintf("  triples %.2f%%, consecutives %.2f%% (%.3f marks a turn), mean value %.2f\n",
+		100*r.TripleRate(), 100*r.ConsecRate(), float64(r.ConsecMarks)/float64(r.Turns), r.MeanValue())
+	cum := 0
+	for _, oc := range r.Ranked() {
+		n := r.Outcomes[oc]
+		cum += n
+		s += fmt.Sprintf("  %12s %9d %6.2f%% %7.2f%%\n", oc, n, 100*r.rate(n), 100*r.rate(cum))
+	}
+	return strings.TrimRight(s, "\n") + "\n"
+}
+
+// PlayTurn - play one turn the way the strategy says, rolling with rng
+func PlayTurn(rules diceturn.Rules, strat Strategy, rng *rand.Rand) (diceturn.DiceTurn, error) {
+	dt := diceturn.NewTurn(strat.Name())
+	dt.ColorDie = rules.ColorDie
+	for dt.NumRolls < 3 {
+		toroll := strat.ToRoll(rules, dt)
+		if toroll == 0 {
+			break
+		}
+		var vals [3]int
+		for d := 0; d < 3; d++ {
+			if toroll&(diceturn.Die0<<d) != 0 {
+				vals[d] = rng.Intn(6) + 1
+			}
+		}
+		if err := dt.TurnRollWith(rules, toroll, vals[0], vals[1], vals[2]); err != nil {
+			return dt, fmt.Errorf("strategy %s: %v", strat.Name(), err)
+		}
+	}
+	if dt.NumRolls == 0 {
+		return dt, fmt.Errorf("strategy %s didn't roll", strat.Name())
+	}
+	// Three rolls closes a turn; standing sooner doesn't
+	if dt.NumRolls < 3 {
+		dt.CloseTurnWith(rules)
+	}
+	return dt, nil
+}
+
+// playChunk - play turns from one seed
+func playChunk(cfg Config, strat Strategy, seed int64, turns int) (Result, error) {
+	res := newResult(strat.Name())
+	rng := rand.New(rand.NewSource(seed))
+	for i := 0; i < turns; i++ {
+		dt, err := PlayTurn(cfg.Rules, strat, rng)
+		if err != nil {
+			return res, err
+		}
+		res.Turns++
+		res.Rolls += dt.NumRolls
+		res.Outcomes[Outcome{dt.Score, dt.ScoreSpecial}]++
+		cs, err := dt.ConsecScoreWith(cfg.Rules, dt.NumRolls)
+		if err != nil {
+			return res, err
+		}
+		if cs > 0 {
+			res.ConsecTurns++
+			res.ConsecMarks += cs
+		}
+	}
+	return res, nil
+}
+
+// Run - play cfg.Turns turns with the strategy, spread over cfg.Workers
+// goroutines
+func Run(cfg Config, strat Strategy) (Result, error) {
+	if cfg.Turns <= 0 {
+		return Result{}, fmt.Errorf("nothing to simulate in %d turns", cfg.Turns)
+	}
+	if err := cfg.Rules.Validate(); err != nil {
+		return Result{}, err
+	}
+	if cfg.Seed == 0 {
+		cfg.Seed = time.Now().UnixNano()
+	}
+
+	res := newResult(strat.Name())
+	res.Seed = cfg.Seed
+	chunks := (cfg.Turns + chunkSize - 1) / chunkSize
+	err := Parallel(chunks, cfg.Workers, func(c int) (Result, error) {
+		turns := chunkSize
+		if left := cfg.Turns - c*chunkSize; left < turns {
+			turns = left
+		}
+		return playChunk(cfg, strat, cfg.Seed+int64(c), turns)
+	}, res.add)
+	return res, err
+}
@@ -0,0 +1,100 @@
+package simulate
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+
+	"wojones.com/src/diceturn"
+)
+
+func TestRunDeterministic(t *testing.T) {
+	cfg := Config{Turns: 25000, Workers: 1, Seed: 42, Rules: diceturn.DefaultRules()}
+	one, err := Run(cfg, Keep{Max: 1})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	cfg.Workers = 4
+	four, err := Run(cfg, Keep{Max: 1})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if !reflect.DeepEqual(one, four) {
+		t.Errorf("Same seed, different results:\n%v\n%v", one, four)
+	}
+	if one.Turns != cfg.Turns {
+		t.Errorf("Played %d turns, expected %d", one.Turns, cfg.Turns)
+	}
+}
+
+func TestRunStand(t *testing.T) {
+	cfg := Config{Turns: 200000, Seed: 7, Rules: diceturn.DefaultRules()}
+	res, err := Run(cfg, Keep{Max: 6})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if res.Rolls != res.Turns {
+		t.Errorf("Standing on the first roll took %d rolls for %d turns", res.Rolls, res.Turns)
+	}
+	// One roll of three dice: 6 triples and 24 runs of consecutives in 216
+	if r := res.TripleRate(); math.Abs(r-6.0/216) > 0.002 {
+		t.Errorf("Triple rate %.4f, expected about %.4f", r, 6.0/216)
+	}
+	if r := res.ConsecRate(); math.Abs(r-24.0/216) > 0.004 {
+		t.Errorf("Consecutives rate %.4f, expected about %.4f", r, 24.0/216)
+	}
+	t.Logf("%v", res)
+}
+
+func TestKeep(t *testing.T) {
+	rules := diceturn.DefaultRules()
+	dt := diceturn.NewTurn("Me")
+	if err := dt.TurnRoll(diceturn.AllDice, 1, 4, 6); err != nil {
+		t.Fatalf("Roll: %v", err)
+	}
+	tests := []struct {
+		strat Strategy
+		want  int
+	}{
+		{Keep{Max: 0}, diceturn.Die0 | diceturn.Die1},
+		{Keep{Max: 1}, diceturn.Die1},
+		{Keep{Max: 6}, 0},
+	}
+	for _, tt := range tests {
+		if got := tt.strat.ToRoll(rules, dt); got != tt.want {
+			t.Errorf("%s rolls 0b%03b after 1/4/6, expected 0b%03b", tt.strat.Name(), got, tt.want)
+		}
+	}
+	// Nothing worth keeping: keep the best die anyway
+	if err := dt.TurnRoll(diceturn.Die0|diceturn.Die1, 4, 5, 0); err != nil {
+		t.Fatalf("Roll: %v", err)
+	}
+	if got := (Keep{Max: 0}).ToRoll(rules, dt); got != diceturn.Die1 {
+		t.Errorf("keep0 rolls 0b%03b after 4/5/6, expected 0b010", got)
+	}
+
+	if _, err := StrategyNamed("keep7"); err == nil {
+		t.Errorf("Found a keep7 strategy")
+	}
+	if s, err := StrategyNamed("keep2"); err != nil || s != (Keep{Max: 2}) {
+		t.Errorf("keep2 is %v (%v)", s, err)
+	}
+}
+
+func TestParallel(t *testing.T) {
+	sum := 0
+	err := Parallel(100, 4, func(job int) (int, error) {
+		if job%10 == 3 {
+			return 0, fmt.Errorf("job %d failed", job)
+		}
+		return job, nil
+	}, func(n int) { sum += n })
+	if err == nil {
+		t.Errorf("Failing jobs didn't fail")
+	}
+	// 0 to 99 add up to 4950; the failed ones, 3 to 93, to 480
+	if sum != 4950-480 {
+		t.Errorf("Folded %d, expected %d", sum, 4950-480)
+	}
+}
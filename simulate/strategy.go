@@ -0,0 +1,82 @@
+package simulate
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"wojones.com/src/diceturn"
+)
+
+// Strategy - how a simulated roller plays a turn: given the turn so far, the
+// dice to roll next (a bitmap), or 0 to stand on what they've got
+type Strategy interface {
+	Name() string
+	ToRoll(rules diceturn.Rules, dt diceturn.DiceTurn) int
+}
+
+// Keep - keep every die worth Max or less and roll the rest, as long as the
+// rules allow it; stand on a triple. If nothing's good enough, keep the best
+// die anyway, since something has to be kept. Keep{0} only keeps zeros (and
+// that best die); Keep{6} stands on the first roll.
+type Keep struct {
+	Max int
+}
+
+func (k Keep) Name() string {
+	return fmt.Sprintf("keep%d", k.Max)
+}
+
+func (k Keep) ToRoll(rules diceturn.Rules, dt diceturn.DiceTurn) int {
+	if dt.NumRolls == 0 {
+		return diceturn.AllDice
+	}
+	last := dt.Rolls[dt.NumRolls-1]
+	if _, special := last.TurnValueWith(rules); special != diceturn.NothingSpecial {
+		return 0
+	}
+
+	// Only the dice rolled last time are still in play
+	toroll, best := 0, -1
+	for d := 0; d < 3; d++ {
+		die := diceturn.Die0 << d
+		if last.Rolled&die == 0 {
+			continue
+		}
+		worth := DieWorth(rules, d, last.RollResults[d])
+		if worth > k.Max {
+			toroll |= die
+		}
+		if best < 0 || worth < DieWorth(rules, best, last.RollResults[best]) {
+			best = d
+		}
+	}
+	if toroll == last.Rolled {
+		toroll &^= diceturn.Die0 << best
+	}
+	if toroll == 0 || dt.RollCheckWith(rules, toroll) != nil {
+		return 0
+	}
+	return toroll
+}
+
+// DieWorth - what die d showing val adds to a roll's value under the rules
+func DieWorth(rules diceturn.Rules, d int, val int) int {
+	if val == diceturn.DieVal0 && rules.SixIsZero {
+		return 0
+	}
+	if d == rules.ColorDie {
+		return val * rules.ColorDieFactor
+	}
+	return val
+}
+
+// StrategyNamed - a strategy by name: keep0 through keep6
+func StrategyNamed(name string) (Strategy, error) {
+	if strings.HasPrefix(name, "keep") {
+		if max, err := strconv.Atoi(strings.TrimPrefix(name, "keep")); err == nil && max >= 0 && max <= 6 {
+			return Keep{Max: max}, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown strategy \"%s\" (try keep0 through keep6)", name)
+}
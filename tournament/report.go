@@ -0,0 +1,162 @@
+package tournament
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// z95 - how many standard errors either side of an estimate make a 95%
+// confidence interval
+const z95 = 1.96
+
+// Standing - how a strategy did, over every seat it played in every game
+type Standing struct {
+	Strategy  string
+	Seats     int // seats played: a game with it in two seats counts twice
+	Lost      int // seats that lost their game
+	Chevrons  int // chevrons filled
+	chevronSq int // sum of the squares of the chevrons each seat filled, for the spread
+}
+
+// LossRate - the fraction of its seats that lost
+func (st Standing) LossRate() float64 {
+	if st.Seats == 0 {
+		return 0
+	}
+	return float64(st.Lost) / float64(st.Seats)
+}
+
+// LossInterval - the 95% confidence interval on the loss rate (Wilson's,
+// which holds up when a strategy hardly ever or nearly always loses)
+func (st Standing) LossInterval() (float64, float64) {
+	if st.Seats == 0 {
+		return 0, 1
+	}
+	n := float64(st.Seats)
+	p := st.LossRate()
+	denom := 1 + z95*z95/n
+	mid := (p + z95*z95/(2*n)) / denom
+	half := z95 * math.Sqrt(p*(1-p)/n+z95*z95/(4*n*n)) / denom
+	return math.Max(0, mid-half), math.Min(1, mid+half)
+}
+
+// ChevronRate - chevrons filled a seat
+func (st Standing) ChevronRate() float64 {
+	if st.Seats == 0 {
+		return 0
+	}
+	return float64(st.Chevrons) / float64(st.Seats)
+}
+
+// ChevronInterval - the 95% confidence interval on chevrons filled a seat
+func (st Standing) ChevronInterval() (float64, float64) {
+	mean := st.ChevronRate()
+	if st.Seats < 2 {
+		return mean, mean
+	}
+	n := float64(st.Seats)
+	variance := (float64(st.chevronSq) - n*mean*mean) / (n - 1)
+	half := z95 * math.Sqrt(math.Max(0, variance)/n)
+	return math.Max(0, mean-half), mean + half
+}
+
+// Result - how a tournament came out
+type Result struct {
+	Seats     []string // the strategies, as seated for the first game
+	Rotate    bool
+	Games     int
+	Rounds    int
+	Seed      int64
+	Rules     string // the rules profile's name
+	Standings map[string]Standing
+}
+
+func newResult(cfg Config) Result {
+	return Result{Seats: cfg.Seats, Rotate: cfg.Rotate, Rounds: cfg.Rounds, Seed: cfg.Seed,
+		Rules: cfg.Rules.Name, Standings: map[string]Standing{}}
+}
+
+// add - fold a game's seats into the standings
+func (r *Result) add(seats []Seat) {
+	r.Games++
+	for _, seat := range seats {
+		st := r.Standings[seat.Strategy]
+		st.Strategy = seat.Strategy
+		st.Seats++
+		st.Chevrons += seat.Chevrons
+		st.chevronSq += seat.Chevrons * seat.Chevrons
+		if seat.Lost {
+			st.Lost++
+		}
+		r.Standings[seat.Strategy] = st
+	}
+}
+
+// Ranked - the standings, least likely to lose first
+func (r Result) Ranked() []Standing {
+	sts := make([]Standing, 0, len(r.Standings))
+	for _, st := range r.Standings {
+		sts = append(sts, st)
+	}
+	sort.Slice(sts, func(i, j int) bool {
+		if sts[i].LossRate() != sts[j].LossRate() {
+			return sts[i].LossRate() < sts[j].LossRate()
+		}
+		return sts[i].Strategy < sts[j].Strategy
+	})
+	return sts
+}
+
+func (r Result) String() string {
+	seating := "fixed seats"
+	if r.Rotate {
+		seating = "rotating seats"
+	}
+	s := fmt.Sprintf("Tournament: %d games of %d round(s), rules \"%s\", seed %d\n", r.Games, r.Rounds, r.Rules, r.Seed)
+	s += fmt.Sprintf("  %s, %s; even odds would lose %.1f%% a seat\n", strings.Join(r.Seats, ", "), seating,
+		100/float64(len(r.Seats)))
+	s += fmt.Sprintf("  %-14s %7s %7s %-22s %8s %s\n", "strategy", "seats", "lost", "loss rate (95% CI)",
+		"chevrons", "a seat (95% CI)")
+	for _, st := range r.Ranked() {
+		llo, lhi := st.LossInterval()
+		clo, chi := st.ChevronInterval()
+		s += fmt.Sprintf("  %-14s %7d %7d %-22s %8d %.3f (%.3f-%.3f)\n", st.Strategy, st.Seats, st.Lost,
+			fmt.Sprintf("%.1f%% (%.1f-%.1f%%)", 100*st.LossRate(), 100*llo, 100*lhi),
+			st.Chevrons, st.ChevronRate(), clo, chi)
+	}
+	return s
+}
+
+// csvHeader - the columns WriteCSV writes
+var csvHeader = []string{"rules", "seats", "rotate", "games", "rounds", "seed", "strategy", "strategy_seats",
+	"lost", "loss_rate", "loss_lo", "loss_hi", "chevrons", "chevron_rate", "chevron_lo", "chevron_hi"}
+
+// WriteCSV - the standings of each result as CSV, a row per strategy under
+// one header, for the spreadsheet
+func WriteCSV(w io.Writer, results ...Result) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	ff := func(f float64) string { return strconv.FormatFloat(f, 'f', 6, 64) }
+	for _, r := range results {
+		for _, st := range r.Ranked() {
+			llo, lhi := st.LossInterval()
+			clo, chi := st.ChevronInterval()
+			row := []string{r.Rules, strings.Join(r.Seats, " "), strconv.FormatBool(r.Rotate), strconv.Itoa(r.Games),
+				strconv.Itoa(r.Rounds), strconv.FormatInt(r.Seed, 10), st.Strategy, strconv.Itoa(st.Seats),
+				strconv.Itoa(st.Lost), ff(st.LossRate()), ff(llo), ff(lhi),
+				strconv.Itoa(st.Chevrons), ff(st.ChevronRate()), ff(clo), ff(chi)}
+			if err := cw.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
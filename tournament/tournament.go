@@ -0,0 +1,149 @@
+package tournament
+
+import (
+	"fmt"
+	"time"
+
+	"wojones.com/src/dicegame"
+	"wojones.com/src/diceturn"
+	"wojones.com/src/simulate"
+)
+
+// Pit the bot strategies against each other: play lots of whole games, every
+// seat a bot, through dicegame, and count who fills the chevrons. Game g rolls
+// dice seeded with the tournament's seed plus g.
+
+// Config - who sits where, how many games, and under which rules
+type Config struct {
+	Seats   []string // the strategy for each seat, in the order they roll
+	Rotate  bool     // move everyone along a seat each game, so no strategy always opens
+	Games   int
+	Rounds  int   // rounds (chevrons filled) a game; 0 for 1
+	Workers int   // as for simulate.Parallel
+	Seed    int64 // 0 to seed from the clock
+	Rules   diceturn.Rules
+}
+
+// seating - the strategy in each seat for game g
+func (cfg Config) seating(g int) []string {
+	seats := make([]string, len(cfg.Seats))
+	for i := range seats {
+		if cfg.Rotate {
+			seats[i] = cfg.Seats[(i+g)%len(cfg.Seats)]
+		} else {
+			seats[i] = cfg.Seats[i]
+		}
+	}
+	return seats
+}
+
+// seatName - the player in seat i (from 0)
+func seatName(i int) string {
+	return fmt.Sprintf("seat%d", i+1)
+}
+
+// Run - play the tournament
+func Run(cfg Config) (Result, error) {
+	if cfg.Games <= 0 {
+		return Result{}, fmt.Errorf("nothing to play in %d games", cfg.Games)
+	}
+	if len(cfg.Seats) < dicegame.MinPlayers || len(cfg.Seats) > dicegame.MaxPlayers {
+		return Result{}, fmt.Errorf("need %d to %d seats, not %d", dicegame.MinPlayers, dicegame.MaxPlayers, len(cfg.Seats))
+	}
+	for _, name := range cfg.Seats {
+		if _, err := dicegame.StrategyNamed(name); err != nil {
+			return Result{}, err
+		}
+	}
+	if err := cfg.Rules.Validate(); err != nil {
+		return Result{}, err
+	}
+	if cfg.Rounds <= 0 {
+		cfg.Rounds = 1
+	}
+	if cfg.Seed == 0 {
+		cfg.Seed = time.Now().UnixNano()
+	}
+
+	res := newResult(cfg)
+	err := simulate.Parallel(cfg.Games, cfg.Workers, func(g int) ([]Seat, error) {
+		return PlayGame(cfg, g)
+	}, res.add)
+	return res, err
+}
+
+// Seat - how one seat came out of a game
+type Seat struct {
+	Player   string
+	Strategy string
+	Chevrons int  // chevrons filled
+	Marks    int  // marks on the chevron still open at the end
+	Lost     bool // filled the most chevrons
+}
+
+// PlayGame - play game g of the tournament (numbered from 0) to the end of
+// its last round, and how each seat came out of it. The loser is whoever
+// filled the most chevrons; between those, whoever's closest to filling the
+// next one; if that's a tie too, they all lost.
+func PlayGame(cfg Config, g int) ([]Seat, error) {
+	rounds := cfg.Rounds
+	if rounds <= 0 {
+		rounds = 1
+	}
+	strats := cfg.seating(g)
+	players := make([]string, len(strats))
+	for i := range players {
+		players[i] = seatName(i)
+	}
+	dg, err := dicegame.NewGame(fmt.Sprintf("tournament-%d", g+1), players...)
+	if err != nil {
+		return nil, err
+	}
+	dg.SetQuiet(true)
+	if err := dg.SetDiceSource(dicegame.NewSeededSource(cfg.Seed + int64(g))); err != nil {
+		return nil, err
+	}
+	if err := dg.SetRules(cfg.Rules); err != nil {
+		return nil, err
+	}
+	// The bots start playing as soon as the one with the dice is seated
+	for i, player := range players {
+		if err := dg.SetBot(player, strats[i]); err != nil {
+			return nil, err
+		}
+	}
+	for round := 1; ; round++ {
+		if s := dg.Snapshot(); !s.Over {
+			return nil, fmt.Errorf("game %d round %d: the bots stopped with %s to roll", g+1, round, s.CurPlayer())
+		}
+		if round == rounds {
+			break
+		}
+		if err := dg.NewRound(); err != nil {
+			return nil, fmt.Errorf("game %d: %v", g+1, err)
+		}
+	}
+
+	s := dg.Snapshot()
+	seats := make([]Seat, len(players))
+	for i, player := range players {
+		seats[i] = Seat{Player: player, Strategy: strats[i]}
+		for _, cv := range s.Scores[player].Chevrons {
+			if cv.Filled {
+				seats[i].Chevrons++
+			} else {
+				seats[i].Marks = int(cv.Count)
+			}
+		}
+	}
+	worst := seats[0]
+	for _, seat := range seats[1:] {
+		if seat.Chevrons > worst.Chevrons || (seat.Chevrons == worst.Chevrons && seat.Marks > worst.Marks) {
+			worst = seat
+		}
+	}
+	for i := range seats {
+		seats[i].Lost = seats[i].Chevrons == worst.Chevrons && seats[i].Marks == worst.Marks
+	}
+	return seats, nil
+}
@@ -0,0 +1,119 @@
+package tournament
+
+import (
+	"bytes"
+	"encoding/csv"
+	"reflect"
+	"testing"
+
+	"wojones.com/src/dicegame"
+	"wojones.com/src/diceturn"
+)
+
+func TestRunDeterministic(t *testing.T) {
+	cfg := Config{Seats: []string{dicegame.BotGreedyLow, dicegame.BotTripleChaser, dicegame.BotGreedyLow},
+		Rotate: true, Games: 60, Workers: 1, Seed: 42, Rules: diceturn.DefaultRules()}
+	one, err := Run(cfg)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	cfg.Workers = 4
+	four, err := Run(cfg)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if !reflect.DeepEqual(one, four) {
+		t.Errorf("Same seed, different results:\n%v\n%v", one, four)
+	}
+
+	if one.Games != cfg.Games {
+		t.Errorf("Played %d games, expected %d", one.Games, cfg.Games)
+	}
+	greedy, chaser := one.Standings[dicegame.BotGreedyLow], one.Standings[dicegame.BotTripleChaser]
+	if greedy.Seats != 2*cfg.Games || chaser.Seats != cfg.Games {
+		t.Errorf("Seats played: greedy-low %d, triple-chaser %d", greedy.Seats, chaser.Seats)
+	}
+	// One round: one chevron filled a game, by whoever lost it
+	if greedy.Lost+chaser.Lost != cfg.Games || greedy.Chevrons+chaser.Chevrons != cfg.Games {
+		t.Errorf("Lost %d and %d, chevrons %d and %d in %d games", greedy.Lost, chaser.Lost,
+			greedy.Chevrons, chaser.Chevrons, cfg.Games)
+	}
+}
+
+func TestPlayGame(t *testing.T) {
+	cfg := Config{Seats: []string{dicegame.BotGreedyLow, dicegame.BotTripleChaser}, Rotate: true,
+		Rounds: 3, Seed: 7, Rules: diceturn.DefaultRules()}
+	for g := 0; g < 2; g++ {
+		seats, err := PlayGame(cfg, g)
+		if err != nil {
+			t.Fatalf("Game %d: %v", g, err)
+		}
+		chevrons, lost := 0, 0
+		for i, seat := range seats {
+			if seat.Strategy != cfg.Seats[(i+g)%2] {
+				t.Errorf("Game %d seat %d: %s, expected %s", g, i, seat.Strategy, cfg.Seats[(i+g)%2])
+			}
+			chevrons += seat.Chevrons
+			if seat.Lost {
+				lost++
+			}
+		}
+		if chevrons != cfg.Rounds || lost == 0 {
+			t.Errorf("Game %d: %d chevrons filled in %d rounds, %d lost", g, chevrons, cfg.Rounds, lost)
+		}
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	rules := diceturn.DefaultRules()
+	for _, cfg := range []Config{
+		{Seats: []string{dicegame.BotGreedyLow, dicegame.BotGreedyLow}, Rules: rules},
+		{Seats: []string{dicegame.BotGreedyLow}, Games: 1, Rules: rules},
+		{Seats: []string{dicegame.BotGreedyLow, "keep1"}, Games: 1, Rules: rules},
+		{Seats: []string{dicegame.BotGreedyLow, dicegame.BotGreedyLow}, Games: 1},
+	} {
+		if _, err := Run(cfg); err == nil {
+			t.Errorf("Run(%+v) didn't fail", cfg)
+		}
+	}
+}
+
+func TestIntervals(t *testing.T) {
+	st := Standing{Seats: 100, Lost: 50, Chevrons: 50, chevronSq: 50}
+	lo, hi := st.LossInterval()
+	if lo > 0.41 || lo < 0.39 || hi < 0.59 || hi > 0.61 {
+		t.Errorf("Loss interval for 50 of 100: %.3f-%.3f", lo, hi)
+	}
+	// Never losing still leaves some doubt
+	st = Standing{Seats: 100}
+	if lo, hi := st.LossInterval(); lo != 0 || hi <= 0 || hi > 0.05 {
+		t.Errorf("Loss interval for 0 of 100: %.3f-%.3f", lo, hi)
+	}
+	st = Standing{Seats: 4, Chevrons: 8, chevronSq: 16}
+	if lo, hi := st.ChevronInterval(); lo != 2 || hi != 2 {
+		t.Errorf("Chevron interval with no spread: %.3f-%.3f", lo, hi)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	cfg := Config{Seats: []string{dicegame.BotGreedyLow, dicegame.BotTripleChaser}, Games: 10, Seed: 3,
+		Rules: diceturn.DefaultRules()}
+	res, err := Run(cfg)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, res, res); err != nil {
+		t.Fatalf("WriteCSV: %v", err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("Reading back the CSV: %v", err)
+	}
+	if len(rows) != 5 || !reflect.DeepEqual(rows[0], csvHeader) {
+		t.Fatalf("Expected a header and 4 rows, got %v", rows)
+	}
+	if rows[1][0] != "default" || rows[1][3] != "10" {
+		t.Errorf("Unexpected row %v", rows[1])
+	}
+}